@@ -0,0 +1,228 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	reductgo "github.com/reductstore/reduct-go"
+)
+
+// tagValueSampleSize is the number of records read to discover the values of a tag.
+const tagValueSampleSize = 10_000
+
+// adhocOperators maps Grafana ad-hoc filter operators onto ReductStore comparison operators.
+var adhocOperators = map[string]string{
+	"=":  "$eq",
+	"!=": "$ne",
+	"<":  "$lt",
+	">":  "$gt",
+	"<=": "$lte",
+	">=": "$gte",
+	"=~": "$regex",
+	"!~": "$regex",
+}
+
+// negatedAdhocOperators are the operators whose comparison is wrapped in "$not".
+var negatedAdhocOperators = map[string]bool{
+	"!~": true,
+}
+
+// applyAdhocFilters ANDs the ad-hoc filters with the condition of the query.
+// Every filter becomes a label comparison appended to the top level "$and" list.
+func applyAdhocFilters(when any, filters []adhocFilter) (any, error) {
+	if len(filters) == 0 {
+		return when, nil
+	}
+
+	condition, err := parseCondition(when)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []any
+	if existing, ok := condition["$and"]; ok {
+		list, ok := existing.([]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid condition: expected array for $and")
+		}
+		terms = list
+	}
+
+	for _, filter := range filters {
+		op, ok := adhocOperators[filter.Operator]
+		if !ok {
+			return nil, fmt.Errorf("unsupported ad-hoc filter operator: %s", filter.Operator)
+		}
+
+		var value any = filter.Value
+		if op != "$regex" {
+			value = parseValue(filter.Value)
+		}
+		var term any = map[string]any{
+			"&" + filter.Key: map[string]any{op: value},
+		}
+		if negatedAdhocOperators[filter.Operator] {
+			term = map[string]any{"$not": term}
+		}
+		terms = append(terms, term)
+	}
+
+	condition["$and"] = terms
+	return condition, nil
+}
+
+// labelSampleRequest is the payload of the resources sampling record labels.
+// Start and stop are in milliseconds like the time range of the frontend.
+type labelSampleRequest struct {
+	Bucket  string   `json:"bucket"`
+	Entries []string `json:"entries"`
+	Key     string   `json:"key"`
+	Start   int64    `json:"start"`
+	Stop    int64    `json:"stop"`
+	Window  string   `json:"window"`
+	Limit   int      `json:"limit"`
+}
+
+func (r labelSampleRequest) timeRange() (time.Time, time.Time) {
+	var from, to time.Time
+	if r.Start > 0 {
+		from = time.UnixMilli(r.Start)
+	}
+	if r.Stop > 0 {
+		to = time.UnixMilli(r.Stop)
+	}
+	return from, to
+}
+
+// sampleRecords runs a head-only query limited to a number of records over the entries of
+// the bucket matching the patterns. An empty pattern list samples every entry.
+func (d *ReductDatasource) sampleRecords(
+	ctx context.Context,
+	bucketName string,
+	patterns []string,
+	when any,
+	from, to time.Time,
+	limit int,
+) (<-chan *reductgo.ReadableRecord, error) {
+	bucket, err := d.reductClient.GetBucket(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := bucket.GetEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range filterEntries(entries, patterns) {
+		names = append(names, entry.Name)
+	}
+	if len(names) == 0 {
+		records := make(chan *reductgo.ReadableRecord)
+		close(records)
+		return records, nil
+	}
+
+	condition, err := withLimit(when, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	options := headQueryOptions(condition, from, to)
+	result, err := bucket.QueryMany(ctx, names, &options)
+	if err != nil {
+		return nil, err
+	}
+	return result.Records(), nil
+}
+
+// sampleBuckets returns the bucket to sample or all buckets if none is given.
+func (d *ReductDatasource) sampleBuckets(ctx context.Context, bucketName string) ([]string, error) {
+	if bucketName != "" {
+		return []string{bucketName}, nil
+	}
+
+	buckets, err := d.reductClient.GetBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		names = append(names, bucket.Name)
+	}
+	return names, nil
+}
+
+func (d *ReductDatasource) handleTagKeys(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var payload labelSampleRequest
+	if len(req.Body) > 0 {
+		if err := json.Unmarshal(req.Body, &payload); err != nil {
+			return sendError(sender, http.StatusBadRequest, "invalid request body")
+		}
+	}
+
+	buckets, err := d.sampleBuckets(ctx, payload.Bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get buckets", "error", err)
+		return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error: %v", err))
+	}
+
+	from, to := payload.timeRange()
+	keys := make(map[string]struct{})
+	for _, bucket := range buckets {
+		records, err := d.sampleRecords(ctx, bucket, payload.Entries, nil, from, to, labelKeySampleSize)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to sample labels", "bucket", bucket, "error", err)
+			return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error sampling labels: %v", err))
+		}
+		for _, key := range collectLabelKeys(records) {
+			keys[key] = struct{}{}
+		}
+	}
+
+	return sendJSON(sender, http.StatusOK, toMetricFindValues(sortedKeys(keys)))
+}
+
+func (d *ReductDatasource) handleTagValues(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var payload labelSampleRequest
+	if err := json.Unmarshal(req.Body, &payload); err != nil || payload.Key == "" {
+		return sendError(sender, http.StatusBadRequest, "missing or invalid 'key' in request")
+	}
+
+	buckets, err := d.sampleBuckets(ctx, payload.Bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get buckets", "error", err)
+		return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error: %v", err))
+	}
+
+	from, to := payload.timeRange()
+	values := make(map[string]struct{})
+	for _, bucket := range buckets {
+		records, err := d.sampleRecords(ctx, bucket, payload.Entries, nil, from, to, tagValueSampleSize)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to sample labels", "bucket", bucket, "error", err)
+			return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error sampling labels: %v", err))
+		}
+		for _, value := range collectLabelValues(records, payload.Key) {
+			values[value] = struct{}{}
+		}
+	}
+
+	return sendJSON(sender, http.StatusOK, toMetricFindValues(sortedKeys(values)))
+}
+
+// toMetricFindValues wraps the values in the shape Grafana expects for tag keys and values.
+func toMetricFindValues(values []string) []map[string]string {
+	result := make([]map[string]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, map[string]string{"text": value})
+	}
+	return result
+}
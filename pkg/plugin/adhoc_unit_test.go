@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyAdhocFilters(t *testing.T) {
+	t.Run("keeps the condition without filters", func(t *testing.T) {
+		when := map[string]any{"&a": map[string]any{"$eq": 1}}
+		result, err := applyAdhocFilters(when, nil)
+		require.NoError(t, err)
+		assert.Equal(t, when, result)
+	})
+
+	t.Run("ands filters with the query condition", func(t *testing.T) {
+		result, err := applyAdhocFilters(`{"&mode": {"$eq": "auto"}}`, []adhocFilter{
+			{Key: "robot", Operator: "=", Value: "r1"},
+			{Key: "battery", Operator: "<", Value: "20"},
+			{Key: "name", Operator: "=~", Value: "^cam-[0-9]+$"},
+			{Key: "site", Operator: "!~", Value: "^test-"},
+		})
+		require.NoError(t, err)
+
+		condition := result.(map[string]any)
+		assert.Equal(t, map[string]any{"$eq": "auto"}, condition["&mode"])
+		assert.Equal(t, []any{
+			map[string]any{"&robot": map[string]any{"$eq": "r1"}},
+			map[string]any{"&battery": map[string]any{"$lt": int64(20)}},
+			map[string]any{"&name": map[string]any{"$regex": "^cam-[0-9]+$"}},
+			map[string]any{"$not": map[string]any{"&site": map[string]any{"$regex": "^test-"}}},
+		}, condition["$and"])
+	})
+
+	t.Run("appends to an existing $and", func(t *testing.T) {
+		result, err := applyAdhocFilters(map[string]any{
+			"$and": []any{map[string]any{"&a": map[string]any{"$gt": 1}}},
+		}, []adhocFilter{{Key: "b", Operator: "!=", Value: "x"}})
+		require.NoError(t, err)
+		assert.Len(t, result.(map[string]any)["$and"], 2)
+	})
+
+	t.Run("rejects unknown operators", func(t *testing.T) {
+		_, err := applyAdhocFilters(nil, []adhocFilter{{Key: "a", Operator: "<>", Value: "x"}})
+		assert.EqualError(t, err, "unsupported ad-hoc filter operator: <>")
+	})
+}
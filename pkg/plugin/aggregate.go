@@ -0,0 +1,144 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// aggregation reduces the values of a window to a single value.
+type aggregation struct {
+	name   string
+	reduce func(values []float64) float64
+}
+
+var aggregations = map[string]func(values []float64) float64{
+	"mean": func(values []float64) float64 {
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	},
+	"min": func(values []float64) float64 {
+		return slices.Min(values)
+	},
+	"max": func(values []float64) float64 {
+		return slices.Max(values)
+	},
+	"sum": func(values []float64) float64 {
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	},
+	"count": func(values []float64) float64 {
+		return float64(len(values))
+	},
+	"first": func(values []float64) float64 {
+		return values[0]
+	},
+	"last": func(values []float64) float64 {
+		return values[len(values)-1]
+	},
+}
+
+// parseAggregations resolves aggregation names. Besides the named ones, "p<N>" is the N-th percentile, e.g. "p95".
+func parseAggregations(names []string) ([]aggregation, error) {
+	result := make([]aggregation, 0, len(names))
+	for _, name := range names {
+		if reduce, ok := aggregations[name]; ok {
+			result = append(result, aggregation{name: name, reduce: reduce})
+			continue
+		}
+
+		p, err := strconv.ParseFloat(strings.TrimPrefix(name, "p"), 64)
+		if !strings.HasPrefix(name, "p") || err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("unknown aggregation: %s", name)
+		}
+		result = append(result, aggregation{name: name, reduce: func(values []float64) float64 {
+			return percentile(values, p)
+		}})
+	}
+	return result, nil
+}
+
+// percentile interpolates linearly between the closest ranks.
+func percentile(values []float64, p float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// aggregateFrames replaces every numeric time series frame by a frame with a value per window of step
+// microseconds and aggregation. Windows without values are left out and other frames are kept as they are.
+func aggregateFrames(frames []*data.Frame, aggs []aggregation, step int64) []*data.Frame {
+	result := make([]*data.Frame, 0, len(frames))
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 || frame.Fields[0].Type() != data.FieldTypeTime || !frame.Fields[1].Type().Numeric() {
+			result = append(result, frame)
+			continue
+		}
+		result = append(result, aggregateFrame(frame, aggs, step))
+	}
+	return result
+}
+
+func aggregateFrame(frame *data.Frame, aggs []aggregation, step int64) *data.Frame {
+	times, values := frame.Fields[0], frame.Fields[1]
+
+	windows := []time.Time{}
+	series := make([][]float64, len(aggs))
+	for i := range series {
+		series[i] = []float64{}
+	}
+	var current []float64
+	var start int64
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		windows = append(windows, time.UnixMicro(start))
+		for i, agg := range aggs {
+			series[i] = append(series[i], agg.reduce(current))
+		}
+		current = current[:0]
+	}
+
+	for i := 0; i < times.Len(); i++ {
+		v, err := values.FloatAt(i)
+		if err != nil || math.IsNaN(v) {
+			continue
+		}
+
+		window := alignTime(times.At(i).(time.Time).UnixMicro(), step)
+		if window != start {
+			flush()
+			start = window
+		}
+		current = append(current, v)
+	}
+	flush()
+
+	fields := []*data.Field{data.NewField("time", nil, windows)}
+	for i, agg := range aggs {
+		fields = append(fields, data.NewField(agg.name, values.Labels, series[i]))
+	}
+
+	aggregated := data.NewFrame(frame.Name, fields...)
+	aggregated.Meta = &data.FrameMeta{
+		Type: data.FrameTypeTimeSeriesWide,
+	}
+	return aggregated
+}
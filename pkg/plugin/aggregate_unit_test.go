@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAggregations(t *testing.T) {
+	aggs, err := parseAggregations([]string{"mean", "p95", "p99.9"})
+	assert.NoError(t, err)
+	assert.Equal(t, "p95", aggs[1].name)
+
+	_, err = parseAggregations([]string{"median"})
+	assert.EqualError(t, err, "unknown aggregation: median")
+
+	_, err = parseAggregations([]string{"p101"})
+	assert.EqualError(t, err, "unknown aggregation: p101")
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{4, 1, 3, 2, 5}
+	assert.Equal(t, 1.0, percentile(values, 0))
+	assert.Equal(t, 3.0, percentile(values, 50))
+	assert.Equal(t, 4.6, percentile(values, 90))
+	assert.Equal(t, 5.0, percentile(values, 100))
+	assert.Equal(t, []float64{4, 1, 3, 2, 5}, values)
+}
+
+func TestAggregateFrames(t *testing.T) {
+	sec := func(s int64) time.Time { return time.Unix(s, 0) }
+	labels := data.Labels{"robot_id": "r1"}
+
+	numeric := data.NewFrame("cam/score",
+		data.NewField("time", nil, []time.Time{sec(1), sec(5), sec(12), sec(31), sec(32)}),
+		data.NewField("value", labels, []int64{1, 3, 10, 7, 2}),
+	)
+	text := data.NewFrame("cam/state",
+		data.NewField("time", nil, []time.Time{sec(1)}),
+		data.NewField("value", nil, []string{"ok"}),
+	)
+
+	aggs, _ := parseAggregations([]string{"min", "max", "mean", "count", "last"})
+	frames := aggregateFrames([]*data.Frame{numeric, text}, aggs, 10_000_000)
+
+	assert.Len(t, frames, 2)
+	assert.Same(t, text, frames[1])
+
+	frame := frames[0]
+	assert.Equal(t, "cam/score", frame.Name)
+	assert.Equal(t, data.FrameTypeTimeSeriesWide, frame.Meta.Type)
+	assert.Equal(t, data.NewField("time", nil, []time.Time{sec(0), sec(10), sec(30)}), frame.Fields[0])
+	assert.Equal(t, data.NewField("min", labels, []float64{1, 10, 2}), frame.Fields[1])
+	assert.Equal(t, data.NewField("max", labels, []float64{3, 10, 7}), frame.Fields[2])
+	assert.Equal(t, data.NewField("mean", labels, []float64{2, 10, 4.5}), frame.Fields[3])
+	assert.Equal(t, data.NewField("count", labels, []float64{2, 1, 2}), frame.Fields[4])
+	assert.Equal(t, data.NewField("last", labels, []float64{3, 10, 2}), frame.Fields[5])
+}
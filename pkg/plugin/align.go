@@ -0,0 +1,174 @@
+package plugin
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Alignment matching modes.
+const (
+	// AlignPrevious takes the last point at or before a grid time.
+	AlignPrevious = "previous"
+	// AlignNearest takes the point closest to a grid time.
+	AlignNearest = "nearest"
+)
+
+// alignOptions resamples series of several entries onto a common grid of the query interval.
+// Series are frame names such as "motor_left/$.current"; without any every numeric series is aligned.
+// A point is only matched to a grid time if it is at most Tolerance away, which defaults to the interval.
+type alignOptions struct {
+	Enabled   bool     `json:"enabled,omitempty"`
+	Series    []string `json:"series,omitempty"`
+	Match     string   `json:"match,omitempty"`
+	Tolerance string   `json:"tolerance,omitempty"`
+}
+
+// tolerance returns the largest distance between a point and the grid time it is matched to.
+func (o alignOptions) tolerance(step time.Duration) (time.Duration, error) {
+	if o.Tolerance == "" {
+		return step, nil
+	}
+
+	tolerance, err := time.ParseDuration(o.Tolerance)
+	if err != nil || tolerance < 0 {
+		return 0, fmt.Errorf("invalid alignment tolerance '%s'", o.Tolerance)
+	}
+	return tolerance, nil
+}
+
+// validate checks the matching mode and the tolerance.
+func (o alignOptions) validate() error {
+	switch o.Match {
+	case "", AlignPrevious, AlignNearest:
+	default:
+		return fmt.Errorf("unknown alignment match: %s", o.Match)
+	}
+	_, err := o.tolerance(time.Second)
+	return err
+}
+
+// alignedSeries is a numeric field of a frame with the times of its points.
+type alignedSeries struct {
+	name   string
+	labels data.Labels
+	times  []time.Time
+	values []*float64
+}
+
+// selectSeries returns the numeric fields of the selected frames. Frames with more than one value field,
+// such as aggregations, give a series per field named after the frame and the field.
+func selectSeries(frames []*data.Frame, names []string) []alignedSeries {
+	var result []alignedSeries
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 || frame.Fields[0].Type() != data.FieldTypeTime {
+			continue
+		}
+		if len(names) > 0 && !slices.Contains(names, frame.Name) {
+			continue
+		}
+
+		times := make([]time.Time, frame.Fields[0].Len())
+		for i := range times {
+			times[i] = frame.Fields[0].At(i).(time.Time)
+		}
+
+		for _, field := range frame.Fields[1:] {
+			if !field.Type().Numeric() {
+				continue
+			}
+
+			name := frame.Name
+			if len(frame.Fields) > 2 {
+				name = frame.Name + " " + field.Name
+			}
+
+			values := make([]*float64, field.Len())
+			for i := range values {
+				if v, err := field.NullableFloatAt(i); err == nil {
+					values[i] = v
+				}
+			}
+			result = append(result, alignedSeries{name: name, labels: field.Labels, times: times, values: values})
+		}
+	}
+	return result
+}
+
+// alignFrames returns a single wide frame with the selected series resampled onto a grid of step between
+// from and to. Without a time range the grid spans the points of the series.
+func alignFrames(frames []*data.Frame, names []string, match string, from, to time.Time, step, tolerance time.Duration) []*data.Frame {
+	series := selectSeries(frames, names)
+
+	if from.IsZero() || to.IsZero() {
+		for _, s := range series {
+			if len(s.times) == 0 {
+				continue
+			}
+			if from.IsZero() || s.times[0].Before(from) {
+				from = s.times[0]
+			}
+			if to.IsZero() || s.times[len(s.times)-1].After(to) {
+				to = s.times[len(s.times)-1]
+			}
+		}
+	}
+
+	grid := []time.Time{}
+	if step > 0 && !from.IsZero() {
+		start := time.UnixMicro(alignTime(from.UnixMicro(), step.Microseconds()))
+		for t := start; !t.After(to); t = t.Add(step) {
+			grid = append(grid, t)
+		}
+	}
+
+	fields := []*data.Field{data.NewField("time", nil, grid)}
+	for _, s := range series {
+		field := data.NewField(s.name, s.labels, matchGrid(s, grid, match, tolerance))
+		field.Config = &data.FieldConfig{DisplayNameFromDS: s.name}
+		fields = append(fields, field)
+	}
+
+	frame := data.NewFrame("aligned", fields...)
+	frame.Meta = &data.FrameMeta{
+		Type: data.FrameTypeTimeSeriesWide,
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(frames, func(f *data.Frame) bool { return f.Name == name }) {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Series '%s' has no data and isn't aligned", name),
+			})
+		}
+	}
+	return []*data.Frame{frame}
+}
+
+// matchGrid picks a value of the series for every grid time. The points must be sorted by time.
+func matchGrid(s alignedSeries, grid []time.Time, match string, tolerance time.Duration) []*float64 {
+	result := make([]*float64, len(grid))
+	// j is the last point at or before the grid time
+	j := -1
+	for i, t := range grid {
+		for j+1 < len(s.times) && !s.times[j+1].After(t) {
+			j++
+		}
+
+		best, distance := -1, tolerance+1
+		if j >= 0 && t.Sub(s.times[j]) <= tolerance {
+			best, distance = j, t.Sub(s.times[j])
+		}
+		if match == AlignNearest && j+1 < len(s.times) {
+			if d := s.times[j+1].Sub(t); d <= tolerance && d < distance {
+				best = j + 1
+			}
+		}
+
+		if best >= 0 {
+			result[i] = s.values[best]
+		}
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignValidate(t *testing.T) {
+	assert.NoError(t, alignOptions{Match: AlignNearest, Tolerance: "500ms"}.validate())
+	assert.EqualError(t, alignOptions{Match: "linear"}.validate(), "unknown alignment match: linear")
+	assert.EqualError(t, alignOptions{Tolerance: "-1s"}.validate(), "invalid alignment tolerance '-1s'")
+}
+
+func newMotorFrames() []*data.Frame {
+	ms := func(v int64) time.Time { return time.UnixMilli(v) }
+	return []*data.Frame{
+		data.NewFrame("motor_left/$.current",
+			data.NewField("time", nil, []time.Time{ms(100), ms(1900), ms(3050)}),
+			data.NewField("value", nil, []float64{1, 2, 3}),
+		),
+		data.NewFrame("motor_right/$.current",
+			data.NewField("time", nil, []time.Time{ms(950), ms(2000)}),
+			data.NewField("value", nil, []int64{10, 20}),
+		),
+		data.NewFrame("motor_right/state",
+			data.NewField("time", nil, []time.Time{ms(950)}),
+			data.NewField("value", nil, []string{"ok"}),
+		),
+	}
+}
+
+func TestAlignFramesPrevious(t *testing.T) {
+	frames := alignFrames(newMotorFrames(), nil, AlignPrevious,
+		time.UnixMilli(0), time.UnixMilli(3000), time.Second, 500*time.Millisecond)
+
+	assert.Len(t, frames, 1)
+	frame := frames[0]
+	assert.Equal(t, "aligned", frame.Name)
+	assert.Len(t, frame.Fields, 3)
+	assert.Equal(t, data.NewField("time", nil, []time.Time{
+		time.UnixMilli(0), time.UnixMilli(1000), time.UnixMilli(2000), time.UnixMilli(3000),
+	}), frame.Fields[0])
+	assert.Equal(t, "motor_left/$.current", frame.Fields[1].Name)
+	assert.Equal(t, floats(nil, nil, 2.0, nil), collect(frame.Fields[1]))
+	assert.Equal(t, floats(nil, 10.0, 20.0, nil), collect(frame.Fields[2]))
+}
+
+func TestAlignFramesNearest(t *testing.T) {
+	frames := alignFrames(newMotorFrames(), []string{"motor_left/$.current", "missing/x"}, AlignNearest,
+		time.UnixMilli(0), time.UnixMilli(3000), time.Second, 500*time.Millisecond)
+
+	frame := frames[0]
+	assert.Len(t, frame.Fields, 2)
+	assert.Equal(t, floats(1.0, nil, 2.0, 3.0), collect(frame.Fields[1]))
+	assert.Len(t, frame.Meta.Notices, 1)
+}
+
+func collect(field *data.Field) []*float64 {
+	values := make([]*float64, field.Len())
+	for i := range values {
+		values[i] = field.At(i).(*float64)
+	}
+	return values
+}
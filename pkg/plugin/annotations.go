@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+)
+
+type annotationEvent struct {
+	time    time.Time
+	timeEnd *time.Time
+	title   string
+	text    string
+	tags    string
+}
+
+// getAnnotationFrames turns records into an annotation frame with time, timeEnd, title, text and tags fields.
+// A record matching the region start opens a region for its entry which is closed by the next record
+// matching the region end. Regions still open at the end of the scan are closed at to. All other
+// records become point annotations.
+func getAnnotationFrames(records <-chan *reductgo.ReadableRecord, options annotationOptions, to time.Time) []*data.Frame {
+	var events []annotationEvent
+	open := make(map[string]*annotationEvent)
+
+	for record := range records {
+		event := newAnnotationEvent(record, options)
+
+		switch {
+		case options.RegionStart.matches(record):
+			if prev, ok := open[record.Entry()]; ok {
+				end := event.time
+				prev.timeEnd = &end
+				events = append(events, *prev)
+			}
+			open[record.Entry()] = &event
+		case options.RegionEnd.matches(record):
+			prev, ok := open[record.Entry()]
+			if !ok {
+				events = append(events, event)
+				continue
+			}
+			end := event.time
+			prev.timeEnd = &end
+			events = append(events, *prev)
+			delete(open, record.Entry())
+		default:
+			events = append(events, event)
+		}
+	}
+
+	for _, event := range open {
+		end := to
+		event.timeEnd = &end
+		events = append(events, *event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].time.Before(events[j].time) })
+
+	times := make([]time.Time, len(events))
+	timeEnds := make([]*time.Time, len(events))
+	titles := make([]string, len(events))
+	texts := make([]string, len(events))
+	tags := make([]string, len(events))
+	for i, event := range events {
+		times[i] = event.time
+		timeEnds[i] = event.timeEnd
+		titles[i] = event.title
+		texts[i] = event.text
+		tags[i] = event.tags
+	}
+
+	return []*data.Frame{
+		data.NewFrame("annotations",
+			data.NewField("time", nil, times),
+			data.NewField("timeEnd", nil, timeEnds),
+			data.NewField("title", nil, titles),
+			data.NewField("text", nil, texts),
+			data.NewField("tags", nil, tags),
+		),
+	}
+}
+
+// newAnnotationEvent builds a point annotation from the record. The title defaults to the entry name
+// and the text to the list of all labels. Tags are "label:value" pairs joined by commas.
+func newAnnotationEvent(record *reductgo.ReadableRecord, options annotationOptions) annotationEvent {
+	labels := record.Labels()
+
+	title := record.Entry()
+	if value, ok := labels[options.Title]; ok && options.Title != "" {
+		title = fmt.Sprintf("%v", value)
+	}
+
+	var text string
+	if options.Text != "" {
+		if value, ok := labels[options.Text]; ok {
+			text = fmt.Sprintf("%v", value)
+		}
+	} else {
+		keys := make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", key, labels[key]))
+		}
+		text = strings.Join(pairs, ", ")
+	}
+
+	tags := make([]string, 0, len(options.Tags))
+	for _, key := range options.Tags {
+		if value, ok := labels[key]; ok {
+			tags = append(tags, fmt.Sprintf("%s:%v", key, value))
+		}
+	}
+
+	return annotationEvent{
+		time:  time.UnixMicro(record.Time()),
+		title: title,
+		text:  text,
+		tags:  strings.Join(tags, ","),
+	}
+}
+
+// matches reports whether the record has the label with the expected value. A nil match never matches.
+func (m *labelMatch) matches(record *reductgo.ReadableRecord) bool {
+	if m == nil || m.Label == "" {
+		return false
+	}
+	value, ok := record.Labels()[m.Label]
+	return ok && fmt.Sprintf("%v", value) == m.Value
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAnnotationFrames(t *testing.T) {
+	records := make(chan *reductgo.ReadableRecord, 4)
+	records <- newHeadRecord("robot", 1_000_000, 0, reductgo.LabelMap{"phase": "start", "mission": "m1"})
+	records <- newHeadRecord("robot", 2_000_000, 0, reductgo.LabelMap{"fault": "overheat", "mission": "m1"})
+	records <- newHeadRecord("robot", 3_000_000, 0, reductgo.LabelMap{"phase": "end", "mission": "m1"})
+	records <- newHeadRecord("other", 4_000_000, 0, reductgo.LabelMap{"phase": "start", "mission": "m2"})
+	close(records)
+
+	options := annotationOptions{
+		Title:       "mission",
+		Tags:        []string{"mission", "fault"},
+		RegionStart: &labelMatch{Label: "phase", Value: "start"},
+		RegionEnd:   &labelMatch{Label: "phase", Value: "end"},
+	}
+
+	frames := getAnnotationFrames(records, options, time.Unix(10, 0))
+	require.Len(t, frames, 1)
+
+	frame := frames[0]
+	require.Equal(t, 3, frame.Rows())
+
+	// region from start to end
+	row := frame.RowCopy(0)
+	assert.Equal(t, time.Unix(1, 0), row[0])
+	assert.Equal(t, time.Unix(3, 0), *row[1].(*time.Time))
+	assert.Equal(t, "m1", row[2])
+	assert.Equal(t, "mission=m1, phase=start", row[3])
+	assert.Equal(t, "mission:m1", row[4])
+
+	// point event
+	row = frame.RowCopy(1)
+	assert.Equal(t, time.Unix(2, 0), row[0])
+	assert.Nil(t, row[1])
+	assert.Equal(t, "mission:m1,fault:overheat", row[4])
+
+	// open region is closed at the end of the range
+	row = frame.RowCopy(2)
+	assert.Equal(t, time.Unix(4, 0), row[0])
+	assert.Equal(t, time.Unix(10, 0), *row[1].(*time.Time))
+}
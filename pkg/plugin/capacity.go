@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	model "github.com/reductstore/reduct-go/model"
+)
+
+// bucketCapacity combines the usage of a bucket with its quota settings.
+type bucketCapacity struct {
+	info     model.BucketInfo
+	settings model.BucketSetting
+}
+
+// queryCapacity returns the server usage and the size of the buckets against their quotas.
+// If bucketName is set, only that bucket is reported.
+func (d *ReductDatasource) queryCapacity(ctx context.Context, bucketName string) backend.DataResponse {
+	serverInfo, err := d.reductClient.GetInfo(ctx)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get server info", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	buckets, err := d.reductClient.GetBuckets(ctx)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get buckets", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	capacities := make([]bucketCapacity, 0, len(buckets))
+	for _, info := range buckets {
+		if bucketName != "" && info.Name != bucketName {
+			continue
+		}
+
+		bucket, err := d.reductClient.GetBucket(ctx, info.Name)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to get bucket", "bucket", info.Name, "error", err)
+			var apiErr model.APIError
+			errors.As(err, &apiErr)
+			return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+		}
+
+		settings, err := bucket.GetSettings(ctx)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to get bucket settings", "bucket", info.Name, "error", err)
+			var apiErr model.APIError
+			errors.As(err, &apiErr)
+			return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+		}
+		capacities = append(capacities, bucketCapacity{info: info, settings: settings})
+	}
+
+	if bucketName != "" && len(capacities) == 0 {
+		return backend.ErrDataResponse(backend.StatusNotFound, "bucket '"+bucketName+"' not found")
+	}
+
+	return backend.DataResponse{
+		Frames: []*data.Frame{
+			getServerCapacityFrame(serverInfo),
+			getBucketCapacityFrame(capacities),
+		},
+	}
+}
+
+// getServerCapacityFrame builds a single-row numeric frame with the server usage for stat panels and alerts.
+// Record times are epoch milliseconds so that they can be shown as dates.
+func getServerCapacityFrame(info model.ServerInfo) *data.Frame {
+	frame := data.NewFrame("server",
+		newUnitField("usage", "bytes", []int64{info.Usage}),
+		newUnitField("bucket_count", "", []int64{info.BucketCount}),
+		newUnitField("uptime", "s", []int64{info.Uptime}),
+		newUnitField("oldest_record", "dateTimeAsIso", []int64{microToMilli(info.OldestRecord)}),
+		newUnitField("latest_record", "dateTimeAsIso", []int64{microToMilli(info.LatestRecord)}),
+	)
+	frame.Meta = &data.FrameMeta{
+		Type: data.FrameTypeNumericWide,
+	}
+	return frame
+}
+
+// getBucketCapacityFrame builds a numeric long frame with one row per bucket. The bucket name and
+// the quota type are string dimensions, so alert rules get a separate instance per bucket.
+// The quota usage is a percentage and null for buckets without a size quota.
+func getBucketCapacityFrame(capacities []bucketCapacity) *data.Frame {
+	sorted := append([]bucketCapacity(nil), capacities...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].info.Name < sorted[j].info.Name })
+
+	names := make([]string, len(sorted))
+	quotaTypes := make([]string, len(sorted))
+	sizes := make([]int64, len(sorted))
+	quotaSizes := make([]int64, len(sorted))
+	quotaUsage := make([]*float64, len(sorted))
+	entryCounts := make([]int64, len(sorted))
+	oldest := make([]int64, len(sorted))
+	latest := make([]int64, len(sorted))
+
+	for i, c := range sorted {
+		names[i] = c.info.Name
+		quotaTypes[i] = string(c.settings.QuotaType)
+		if quotaTypes[i] == "" {
+			quotaTypes[i] = string(model.QuotaTypeNone)
+		}
+		sizes[i] = c.info.Size
+		quotaSizes[i] = c.settings.QuotaSize
+		entryCounts[i] = c.info.EntryCount
+		oldest[i] = microToMilli(c.info.OldestRecord)
+		latest[i] = microToMilli(c.info.LatestRecord)
+
+		if c.settings.QuotaSize > 0 && quotaTypes[i] != string(model.QuotaTypeNone) {
+			usage := float64(c.info.Size) / float64(c.settings.QuotaSize) * 100
+			quotaUsage[i] = &usage
+		}
+	}
+
+	frame := data.NewFrame("buckets",
+		data.NewField("bucket", nil, names),
+		data.NewField("quota_type", nil, quotaTypes),
+		newUnitField("size", "bytes", sizes),
+		newUnitField("quota_size", "bytes", quotaSizes),
+		newUnitField("quota_usage", "percent", quotaUsage),
+		newUnitField("entry_count", "", entryCounts),
+		newUnitField("oldest_record", "dateTimeAsIso", oldest),
+		newUnitField("latest_record", "dateTimeAsIso", latest),
+	)
+	frame.Meta = &data.FrameMeta{
+		Type: data.FrameTypeNumericLong,
+	}
+	return frame
+}
+
+func newUnitField(name string, unit string, values any) *data.Field {
+	field := data.NewField(name, nil, values)
+	if unit != "" {
+		field.Config = &data.FieldConfig{Unit: unit}
+	}
+	return field
+}
+
+func microToMilli(ts int64) int64 {
+	return ts / 1000
+}
@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	model "github.com/reductstore/reduct-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetServerCapacityFrame(t *testing.T) {
+	frame := getServerCapacityFrame(model.ServerInfo{
+		Usage:        1024,
+		BucketCount:  2,
+		Uptime:       60,
+		OldestRecord: 1_000_000,
+		LatestRecord: 2_000_000,
+	})
+
+	require.Equal(t, 1, frame.Rows())
+	assert.Equal(t, data.FrameTypeNumericWide, frame.Meta.Type)
+	assert.Equal(t, int64(1024), frame.Fields[0].At(0))
+	assert.Equal(t, "bytes", frame.Fields[0].Config.Unit)
+	assert.Equal(t, int64(1000), frame.Fields[3].At(0))
+	assert.Equal(t, int64(2000), frame.Fields[4].At(0))
+}
+
+func TestGetBucketCapacityFrame(t *testing.T) {
+	frame := getBucketCapacityFrame([]bucketCapacity{
+		{
+			info:     model.BucketInfo{Name: "b", Size: 50},
+			settings: model.BucketSetting{QuotaType: model.QuotaTypeFifo, QuotaSize: 200},
+		},
+		{
+			info: model.BucketInfo{Name: "a", Size: 10},
+		},
+	})
+
+	require.Equal(t, 2, frame.Rows())
+	assert.Equal(t, data.FrameTypeNumericLong, frame.Meta.Type)
+
+	row := frame.RowCopy(0)
+	assert.Equal(t, "a", row[0])
+	assert.Equal(t, "NONE", row[1])
+	assert.Nil(t, row[4])
+
+	row = frame.RowCopy(1)
+	assert.Equal(t, "b", row[0])
+	assert.Equal(t, "FIFO", row[1])
+	assert.Equal(t, int64(50), row[2])
+	assert.Equal(t, int64(200), row[3])
+	assert.Equal(t, 25.0, *row[4].(*float64))
+}
@@ -0,0 +1,212 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/reductstore/reduct-go/model"
+)
+
+const (
+	// defaultContentSampleSize is the number of records decoded when the request doesn't set a limit.
+	defaultContentSampleSize = 10
+	// maxContentSampleSize protects the plugin from downloading too many bodies.
+	maxContentSampleSize = 100
+	// defaultContentWindow is how far back from the latest record the sample is taken.
+	defaultContentWindow = time.Hour
+	// maxContentExamples is the number of distinct example values returned per path.
+	maxContentExamples = 3
+)
+
+// Content value types reported by the schema discovery.
+const (
+	ContentTypeNumber = "number"
+	ContentTypeString = "string"
+	ContentTypeBool   = "bool"
+	ContentTypeNull   = "null"
+)
+
+// decodeContent decodes a record body into flattened "$.path" values. It returns false if the
+// body can't be decoded.
+func decodeContent(b []byte) (map[string]any, bool) {
+	if len(strings.TrimSpace(string(b))) == 0 || !looksLikeJSON(b) {
+		return nil, false
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, false
+	}
+
+	flat := map[string]any{}
+	flattenJSON("$", v, flat)
+	return flat, true
+}
+
+// contentPath describes a flattened path observed in the sampled bodies.
+type contentPath struct {
+	Path     string   `json:"path"`
+	Type     string   `json:"type"`
+	Count    int64    `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// contentTypeOf returns the type of a decoded content value.
+func contentTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return ContentTypeNull
+	case float64, int64:
+		return ContentTypeNumber
+	case bool:
+		return ContentTypeBool
+	default:
+		return ContentTypeString
+	}
+}
+
+// mergeContentTypes returns a type able to hold values of both types. Nulls don't change the type,
+// every other mix falls back to strings.
+func mergeContentTypes(a, b string) string {
+	switch {
+	case a == "" || a == ContentTypeNull || a == b:
+		return b
+	case b == ContentTypeNull:
+		return a
+	default:
+		return ContentTypeString
+	}
+}
+
+// inferContentSchema decodes the bodies of the records and returns the union of their paths.
+func inferContentSchema(records <-chan *reductgo.ReadableRecord) []contentPath {
+	paths := make(map[string]*contentPath)
+	for record := range records {
+		b, err := record.Read()
+		if err != nil {
+			log.DefaultLogger.Error("Failed to read record", "entry", record.Entry(), "time", record.Time(), "error", err)
+			continue
+		}
+
+		flat, ok := decodeContent(b)
+		if !ok {
+			continue
+		}
+
+		for key, value := range flat {
+			path, ok := paths[key]
+			if !ok {
+				path = &contentPath{Path: key, Examples: []string{}}
+				paths[key] = path
+			}
+			path.Count++
+			path.Type = mergeContentTypes(path.Type, contentTypeOf(value))
+
+			if value == nil {
+				continue
+			}
+			str := fmt.Sprintf("%v", value)
+			if len(path.Examples) < maxContentExamples && !slices.Contains(path.Examples, str) {
+				path.Examples = append(path.Examples, str)
+			}
+		}
+	}
+
+	result := make([]contentPath, 0, len(paths))
+	for _, key := range sortedKeys(paths) {
+		result = append(result, *paths[key])
+	}
+	return result
+}
+
+// sampleStart returns the start of a range ending with the latest record of the entry which holds about
+// limit records at the average rate of the entry. The range is at most window long.
+func sampleStart(entry model.EntryInfo, limit int, window time.Duration) int64 {
+	span := window.Microseconds()
+	if entry.RecordCount > 1 && entry.LatestRecord > entry.OldestRecord {
+		interval := (entry.LatestRecord - entry.OldestRecord) / (entry.RecordCount - 1)
+		span = min(span, max(interval, 1)*int64(limit))
+	}
+	return entry.LatestRecord - span
+}
+
+func (d *ReductDatasource) handleContentSchema(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var payload struct {
+		Bucket string `json:"bucket"`
+		Entry  string `json:"entry"`
+		Limit  int    `json:"limit"`
+		Window string `json:"window"`
+	}
+
+	if err := json.Unmarshal(req.Body, &payload); err != nil || payload.Bucket == "" || payload.Entry == "" {
+		log.DefaultLogger.Warn("Missing or invalid bucket/entry in request")
+		return sendError(sender, http.StatusBadRequest, "missing or invalid 'bucket' or 'entry' in request")
+	}
+
+	limit := defaultContentSampleSize
+	if payload.Limit > 0 {
+		limit = min(payload.Limit, maxContentSampleSize)
+	}
+
+	window := defaultContentWindow
+	if payload.Window != "" {
+		parsed, err := time.ParseDuration(payload.Window)
+		if err != nil || parsed <= 0 {
+			return sendError(sender, http.StatusBadRequest, fmt.Sprintf("invalid window '%s'", payload.Window))
+		}
+		window = parsed
+	}
+
+	bucket, err := d.reductClient.GetBucket(ctx, payload.Bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get bucket", "bucket", payload.Bucket, "error", err)
+		return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error getting bucket: %v", err))
+	}
+
+	entries, err := bucket.GetEntries(ctx)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to list entries", "error", err)
+		return sendError(sender, http.StatusInternalServerError, "error getting entries")
+	}
+
+	var info model.EntryInfo
+	found := false
+	for _, entry := range entries {
+		if entry.Name == payload.Entry {
+			info, found = entry, true
+			break
+		}
+	}
+	if !found {
+		return sendError(sender, http.StatusNotFound, fmt.Sprintf("entry '%s' not found", payload.Entry))
+	}
+
+	// a query returns the oldest records of its range first, so the range is narrowed to end with the latest ones
+	options := reductgo.NewQueryOptionsBuilder().
+		WithWhen(map[string]any{"$limit": limit}).
+		WithStart(sampleStart(info, limit, window)).
+		WithStop(info.LatestRecord + 1).
+		Build()
+
+	records, err := bucket.Query(ctx, payload.Entry, &options)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to query", "error", err)
+		var apiErr model.APIError
+		errorMsg := "failed to sample records"
+		if errors.As(err, &apiErr) {
+			errorMsg = apiErr.Message
+		}
+		return sendError(sender, http.StatusInternalServerError, errorMsg)
+	}
+
+	return sendJSON(sender, http.StatusOK, inferContentSchema(records.Records()))
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/reductstore/reduct-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeContent(t *testing.T) {
+	flat, ok := decodeContent([]byte(`{"a": {"b": 1}, "c": [true, "x"]}`))
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"$.a.b": float64(1), "$.c[0]": true, "$.c[1]": "x"}, flat)
+
+	_, ok = decodeContent([]byte("plain text"))
+	assert.False(t, ok)
+
+	_, ok = decodeContent([]byte("  "))
+	assert.False(t, ok)
+
+	_, ok = decodeContent([]byte("{broken"))
+	assert.False(t, ok)
+}
+
+func TestInferContentSchema(t *testing.T) {
+	records := make(chan *reductgo.ReadableRecord, 3)
+	for _, body := range []string{
+		`{"temp": 20.5, "state": "ok", "extra": null}`,
+		`{"temp": 21, "state": "warn", "extra": 1}`,
+		`not json`,
+	} {
+		records <- reductgo.NewReadableRecord("e", 1, 0, false, io.NopCloser(strings.NewReader(body)), nil, "")
+	}
+	close(records)
+
+	schema := inferContentSchema(records)
+	require.Len(t, schema, 3)
+	assert.Equal(t, contentPath{Path: "$.extra", Type: ContentTypeNumber, Count: 2, Examples: []string{"1"}}, schema[0])
+	assert.Equal(t, contentPath{Path: "$.state", Type: ContentTypeString, Count: 2, Examples: []string{"ok", "warn"}}, schema[1])
+	assert.Equal(t, contentPath{Path: "$.temp", Type: ContentTypeNumber, Count: 2, Examples: []string{"20.5", "21"}}, schema[2])
+}
+
+func TestSampleStart(t *testing.T) {
+	// 10 Hz for an hour: 100 records are the last 10 seconds
+	entry := model.EntryInfo{RecordCount: 36_001, OldestRecord: 0, LatestRecord: 3_600_000_000}
+	assert.Equal(t, int64(3_590_000_000), sampleStart(entry, 100, time.Hour))
+
+	// a sparse entry is sampled over the whole window
+	entry = model.EntryInfo{RecordCount: 3, OldestRecord: 0, LatestRecord: 7_200_000_000}
+	assert.Equal(t, int64(3_600_000_000), sampleStart(entry, 100, time.Hour))
+
+	entry = model.EntryInfo{RecordCount: 1, OldestRecord: 5, LatestRecord: 5}
+	assert.Equal(t, 5-time.Hour.Microseconds(), sampleStart(entry, 100, time.Hour))
+}
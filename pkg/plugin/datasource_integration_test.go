@@ -350,3 +350,54 @@ func TestQueryData_ContentMode_PreservesJSONStringTypes(t *testing.T) {
 	assert.Equal(t, 10, dr.Frames[idx].Rows())
 	assert.Equal(t, "00000001_000", dr.Frames[idx].Fields[1].At(0))
 }
+
+func TestQueryData_Latest(t *testing.T) {
+	resp, teardown, _ := runQuery(t, func(bucket string) string {
+		return fmt.Sprintf(`{
+			"queryType": "latest",
+			"Bucket": "%s",
+			"Entries": ["entity*"],
+			"Options": { "Mode": "LabelAndContent" }
+		}`, bucket)
+	})
+	defer teardown(t)
+
+	dr := resp.Responses["A"]
+	assert.Nil(t, dr.Error)
+
+	idx := findByName(&resp, "entity1")
+	if idx == -1 {
+		t.Fatalf("frame entity1 not found")
+	}
+	frame := dr.Frames[idx]
+	assert.Equal(t, 1, frame.Rows())
+
+	field, _ := frame.FieldByName("int-label")
+	assert.Equal(t, int64(9), field.At(0))
+	field, _ = frame.FieldByName("$.temp")
+	assert.Equal(t, 9.25, field.At(0))
+}
+
+func TestQueryData_Snapshot(t *testing.T) {
+	resp, teardown, _ := runQuery(t, func(bucket string) string {
+		return fmt.Sprintf(`{
+			"queryType": "snapshot",
+			"Bucket": "%s",
+			"Entries": ["entity*"]
+		}`, bucket)
+	})
+	defer teardown(t)
+
+	dr := resp.Responses["A"]
+	assert.Nil(t, dr.Error)
+	if len(dr.Frames) != 1 {
+		t.Fatalf("expected a single frame, got %d", len(dr.Frames))
+	}
+
+	frame := dr.Frames[0]
+	assert.Equal(t, 1, frame.Rows())
+	assert.Equal(t, "entity1", frame.Fields[0].At(0))
+
+	field, _ := frame.FieldByName("string-label")
+	assert.Equal(t, "label-9", *field.At(0).(*string))
+}
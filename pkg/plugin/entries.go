@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"strings"
+
+	model "github.com/reductstore/reduct-go/model"
+)
+
+// matchEntryPattern reports whether an entry name matches a pattern where "*" stands for any
+// sequence of characters, the same wildcard syntax accepted by QueryMany.
+func matchEntryPattern(pattern, name string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == name
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(name, part)
+		if idx < 0 {
+			return false
+		}
+		name = name[idx+len(part):]
+	}
+	return strings.HasSuffix(name, last)
+}
+
+// filterEntries returns the entries matching any of the patterns. An empty pattern list matches every entry.
+func filterEntries(entries []model.EntryInfo, patterns []string) []model.EntryInfo {
+	if len(patterns) == 0 {
+		return entries
+	}
+
+	result := make([]model.EntryInfo, 0, len(entries))
+	for _, entry := range entries {
+		for _, pattern := range patterns {
+			if matchEntryPattern(pattern, entry.Name) {
+				result = append(result, entry)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package plugin
+
+import (
+	"testing"
+
+	model "github.com/reductstore/reduct-go/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchEntryPattern(t *testing.T) {
+	assert.True(t, matchEntryPattern("sensor-1", "sensor-1"))
+	assert.False(t, matchEntryPattern("sensor-1", "sensor-10"))
+	assert.True(t, matchEntryPattern("sensor-*", "sensor-10"))
+	assert.True(t, matchEntryPattern("*", "anything"))
+	assert.True(t, matchEntryPattern("robot-*/camera", "robot-1/camera"))
+	assert.False(t, matchEntryPattern("robot-*/camera", "robot-1/lidar"))
+	assert.True(t, matchEntryPattern("*-*-x", "a-b-x"))
+	assert.False(t, matchEntryPattern("a*a", "a"))
+}
+
+func TestFilterEntries(t *testing.T) {
+	entries := []model.EntryInfo{{Name: "cam-1"}, {Name: "cam-2"}, {Name: "lidar"}}
+
+	assert.Len(t, filterEntries(entries, nil), 3)
+	assert.Equal(t, []model.EntryInfo{{Name: "cam-1"}, {Name: "cam-2"}}, filterEntries(entries, []string{"cam-*"}))
+	assert.Equal(t, []model.EntryInfo{{Name: "lidar"}}, filterEntries(entries, []string{"lidar", "missing"}))
+}
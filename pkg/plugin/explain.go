@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/reductstore/reduct-go/model"
+)
+
+// explainRecordLimit is the number of matching record headers returned by explainQuery.
+const explainRecordLimit = 5
+
+// explainedEntry is an entry resolved from the query patterns with an estimate of its records in the range.
+type explainedEntry struct {
+	Name             string `json:"name"`
+	RecordCount      int64  `json:"record_count"`
+	OldestRecord     int64  `json:"oldest_record"`
+	LatestRecord     int64  `json:"latest_record"`
+	EstimatedRecords int64  `json:"estimated_records"`
+}
+
+// recordHeader is the metadata of a record without its body.
+type recordHeader struct {
+	Entry       string         `json:"entry"`
+	Time        int64          `json:"time"`
+	Size        int64          `json:"size"`
+	ContentType string         `json:"content_type"`
+	Labels      map[string]any `json:"labels"`
+}
+
+// queryExplanation tells why a query returns what it returns. Times are in microseconds.
+type queryExplanation struct {
+	Entries           []explainedEntry `json:"entries"`
+	UnmatchedPatterns []string         `json:"unmatched_patterns"`
+	When              map[string]any   `json:"when"`
+	Start             int64            `json:"start"`
+	Stop              int64            `json:"stop"`
+	Records           []recordHeader   `json:"records"`
+	Error             string           `json:"error,omitempty"`
+}
+
+// resolveEntries returns the entries matching the patterns and the patterns matching no entry.
+func resolveEntries(entries []model.EntryInfo, patterns []string) ([]model.EntryInfo, []string) {
+	unmatched := []string{}
+	for _, pattern := range patterns {
+		if len(filterEntries(entries, []string{pattern})) == 0 {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	return filterEntries(entries, patterns), unmatched
+}
+
+// estimateRecords assumes the records of an entry are evenly spread between its oldest and latest
+// record and returns the share falling into the range.
+func estimateRecords(entry model.EntryInfo, start, stop int64) int64 {
+	if entry.RecordCount == 0 || entry.LatestRecord < start || entry.OldestRecord > stop {
+		return 0
+	}
+
+	span := entry.LatestRecord - entry.OldestRecord
+	if span <= 0 {
+		return entry.RecordCount
+	}
+
+	overlap := min(stop, entry.LatestRecord) - max(start, entry.OldestRecord)
+	return int64(math.Round(float64(entry.RecordCount) * float64(overlap) / float64(span)))
+}
+
+func (d *ReductDatasource) handleExplainQuery(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var payload struct {
+		Query    reductQuery `json:"query"`
+		From     int64       `json:"from"`
+		To       int64       `json:"to"`
+		Interval string      `json:"interval"`
+	}
+
+	if err := json.Unmarshal(req.Body, &payload); err != nil || payload.Query.Bucket == "" {
+		log.DefaultLogger.Warn("Missing or invalid query in request")
+		return sendError(sender, http.StatusBadRequest, "missing or invalid 'query' in request")
+	}
+
+	patterns := payload.Query.Entries
+	if len(patterns) == 0 && payload.Query.Entry != "" {
+		patterns = []string{payload.Query.Entry}
+	}
+
+	// the time range falls back to the one injected into the query options by the frontend
+	from, to := payload.From, payload.To
+	if from == 0 {
+		from = payload.Query.Options.Start
+	}
+	if to == 0 {
+		to = payload.Query.Options.Stop
+	}
+
+	explanation := queryExplanation{
+		Entries: []explainedEntry{},
+		Records: []recordHeader{},
+		Start:   time.UnixMilli(from).UnixMicro(),
+		Stop:    time.UnixMilli(to).UnixMicro(),
+	}
+	if to == 0 {
+		explanation.Stop = math.MaxInt64
+	}
+
+	interval := payload.Interval
+	if interval == "" {
+		interval = "1s"
+	}
+
+	when, err := applyAdhocFilters(payload.Query.Options.When, payload.Query.AdhocFilters)
+	if err == nil {
+		explanation.When, err = parseCondition(when)
+	}
+	if err != nil {
+		explanation.Error = err.Error()
+		return sendJSON(sender, http.StatusOK, explanation)
+	}
+	explanation.When = replaceIntervalMacros(explanation.When, interval).(map[string]any)
+
+	bucket, err := d.reductClient.GetBucket(ctx, payload.Query.Bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get bucket", "bucket", payload.Query.Bucket, "error", err)
+		explanation.Error = fmt.Sprintf("Failed to access bucket '%s'", payload.Query.Bucket)
+		var apiErr model.APIError
+		if errors.As(err, &apiErr) {
+			explanation.Error = apiErr.Message
+		}
+		return sendJSON(sender, http.StatusOK, explanation)
+	}
+
+	entries, err := bucket.GetEntries(ctx)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to list entries", "error", err)
+		return sendError(sender, http.StatusInternalServerError, "error getting entries")
+	}
+
+	matched, unmatched := resolveEntries(entries, patterns)
+	explanation.UnmatchedPatterns = unmatched
+
+	names := make([]string, 0, len(matched))
+	for _, entry := range matched {
+		names = append(names, entry.Name)
+		explanation.Entries = append(explanation.Entries, explainedEntry{
+			Name:             entry.Name,
+			RecordCount:      entry.RecordCount,
+			OldestRecord:     entry.OldestRecord,
+			LatestRecord:     entry.LatestRecord,
+			EstimatedRecords: estimateRecords(entry, explanation.Start, explanation.Stop),
+		})
+	}
+
+	if len(names) == 0 {
+		return sendJSON(sender, http.StatusOK, explanation)
+	}
+
+	// clone the condition so that the limit doesn't show up in the reported condition
+	condition, err := withLimit(maps.Clone(explanation.When), explainRecordLimit)
+	if err != nil {
+		explanation.Error = err.Error()
+		return sendJSON(sender, http.StatusOK, explanation)
+	}
+
+	var start, stop time.Time
+	if from != 0 {
+		start = time.UnixMilli(from)
+	}
+	if to != 0 {
+		stop = time.UnixMilli(to)
+	}
+
+	options := headQueryOptions(condition, start, stop)
+	records, err := bucket.QueryMany(ctx, names, &options)
+	if err != nil {
+		log.DefaultLogger.Debug("Explain query failed", "error", err)
+		explanation.Error = "Query failed"
+		var apiErr model.APIError
+		if errors.As(err, &apiErr) {
+			explanation.Error = apiErr.Message
+		}
+		return sendJSON(sender, http.StatusOK, explanation)
+	}
+
+	for record := range records.Records() {
+		explanation.Records = append(explanation.Records, recordHeader{
+			Entry:       record.Entry(),
+			Time:        record.Time(),
+			Size:        record.Size(),
+			ContentType: record.ContentType(),
+			Labels:      record.Labels(),
+		})
+	}
+
+	return sendJSON(sender, http.StatusOK, explanation)
+}
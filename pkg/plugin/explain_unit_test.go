@@ -0,0 +1,27 @@
+package plugin
+
+import (
+	"testing"
+
+	model "github.com/reductstore/reduct-go/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEntries(t *testing.T) {
+	entries := []model.EntryInfo{{Name: "cam-1"}, {Name: "cam-2"}, {Name: "lidar"}}
+
+	matched, unmatched := resolveEntries(entries, []string{"cam-*", "radar-*"})
+	assert.Equal(t, []model.EntryInfo{{Name: "cam-1"}, {Name: "cam-2"}}, matched)
+	assert.Equal(t, []string{"radar-*"}, unmatched)
+}
+
+func TestEstimateRecords(t *testing.T) {
+	entry := model.EntryInfo{RecordCount: 100, OldestRecord: 0, LatestRecord: 1000}
+
+	assert.Equal(t, int64(100), estimateRecords(entry, -10, 2000))
+	assert.Equal(t, int64(50), estimateRecords(entry, 500, 2000))
+	assert.Equal(t, int64(10), estimateRecords(entry, 100, 200))
+	assert.Equal(t, int64(0), estimateRecords(entry, 2000, 3000))
+	assert.Equal(t, int64(0), estimateRecords(model.EntryInfo{}, 0, 1000))
+	assert.Equal(t, int64(1), estimateRecords(model.EntryInfo{RecordCount: 1, OldestRecord: 5, LatestRecord: 5}, 0, 10))
+}
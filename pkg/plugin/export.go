@@ -0,0 +1,358 @@
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/reductstore/reduct-go/model"
+)
+
+const (
+	// defaultExportRecords is the number of exported records when the request doesn't set a limit.
+	defaultExportRecords = 10000
+	// maxExportRecords caps the limit of a request.
+	maxExportRecords = 100000
+	// maxExportBytes stops an export once the bodies written exceed it.
+	maxExportBytes = 1 << 30
+	// exportChunkSize is the size of the chunks sent to Grafana while the export is running.
+	exportChunkSize = 1 << 20
+	// exportManifestName is the file listing the labels of the exported records in an archive.
+	exportManifestName = "manifest.json"
+)
+
+// Export formats.
+const (
+	ExportTar    = "tar"
+	ExportZip    = "zip"
+	ExportNDJSON = "ndjson"
+)
+
+var exportContentTypes = map[string]string{
+	ExportTar:    "application/x-tar",
+	ExportZip:    "application/zip",
+	ExportNDJSON: "application/x-ndjson",
+}
+
+// exportedRecord describes a record of an export. File is its path in an archive, Body is only set in NDJSON.
+type exportedRecord struct {
+	File        string         `json:"file,omitempty"`
+	Entry       string         `json:"entry"`
+	Time        int64          `json:"time"`
+	Size        int64          `json:"size"`
+	ContentType string         `json:"content_type"`
+	Labels      map[string]any `json:"labels"`
+	Body        string         `json:"body,omitempty"`
+}
+
+// exportManifest is written at the end of an archive.
+type exportManifest struct {
+	Records   []exportedRecord `json:"records"`
+	Truncated bool             `json:"truncated"`
+}
+
+// recordArchive writes exported records in one of the export formats.
+type recordArchive interface {
+	add(record *reductgo.ReadableRecord) error
+	close(manifest exportManifest) error
+}
+
+func newRecordArchive(format string, w io.Writer) (recordArchive, error) {
+	switch format {
+	case ExportTar:
+		return &tarArchive{writer: tar.NewWriter(w)}, nil
+	case ExportZip:
+		return &zipArchive{writer: zip.NewWriter(w)}, nil
+	case ExportNDJSON:
+		return &ndjsonArchive{writer: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format '%s'", format)
+	}
+}
+
+// exportFileName returns the path of a record in an archive with an extension matching its content type.
+func exportFileName(record *reductgo.ReadableRecord) string {
+	ext := ".bin"
+	if exts, err := mime.ExtensionsByType(record.ContentType()); err == nil && len(exts) > 0 {
+		ext = exts[0]
+	}
+	return fmt.Sprintf("%s/%d%s", record.Entry(), record.Time(), ext)
+}
+
+func newExportedRecord(record *reductgo.ReadableRecord) exportedRecord {
+	return exportedRecord{
+		Entry:       record.Entry(),
+		Time:        record.Time(),
+		Size:        record.Size(),
+		ContentType: record.ContentType(),
+		Labels:      record.Labels(),
+	}
+}
+
+type tarArchive struct {
+	writer *tar.Writer
+}
+
+func (a *tarArchive) add(record *reductgo.ReadableRecord) error {
+	err := a.writer.WriteHeader(&tar.Header{
+		Name:    exportFileName(record),
+		Mode:    0o644,
+		Size:    record.Size(),
+		ModTime: time.UnixMicro(record.Time()),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(a.writer, record.Stream())
+	return err
+}
+
+func (a *tarArchive) close(manifest exportManifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = a.writer.WriteHeader(&tar.Header{
+		Name:    exportManifestName,
+		Mode:    0o644,
+		Size:    int64(len(b)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := a.writer.Write(b); err != nil {
+		return err
+	}
+	return a.writer.Close()
+}
+
+type zipArchive struct {
+	writer *zip.Writer
+}
+
+func (a *zipArchive) add(record *reductgo.ReadableRecord) error {
+	w, err := a.writer.CreateHeader(&zip.FileHeader{
+		Name:     exportFileName(record),
+		Method:   zip.Deflate,
+		Modified: time.UnixMicro(record.Time()),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, record.Stream())
+	return err
+}
+
+func (a *zipArchive) close(manifest exportManifest) error {
+	w, err := a.writer.Create(exportManifestName)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+	return a.writer.Close()
+}
+
+// ndjsonArchive writes a line with the labels and the base64 body per record. It has no manifest, but
+// a truncated export ends with the line {"truncated":true}.
+type ndjsonArchive struct {
+	writer io.Writer
+}
+
+func (a *ndjsonArchive) add(record *reductgo.ReadableRecord) error {
+	b, err := record.Read()
+	if err != nil {
+		return err
+	}
+
+	line := newExportedRecord(record)
+	line.Body = base64.StdEncoding.EncodeToString(b)
+	return json.NewEncoder(a.writer).Encode(line)
+}
+
+func (a *ndjsonArchive) close(manifest exportManifest) error {
+	if !manifest.Truncated {
+		return nil
+	}
+	return json.NewEncoder(a.writer).Encode(map[string]bool{"truncated": true})
+}
+
+// chunkWriter sends everything written to it in chunks of exportChunkSize. The first chunk carries
+// the status and the headers of the response.
+type chunkWriter struct {
+	sender  backend.CallResourceResponseSender
+	headers map[string][]string
+	buffer  bytes.Buffer
+	started bool
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n, _ := w.buffer.Write(p)
+	if w.buffer.Len() >= exportChunkSize {
+		return n, w.Flush()
+	}
+	return n, nil
+}
+
+// Flush sends the buffered data.
+func (w *chunkWriter) Flush() error {
+	if w.buffer.Len() == 0 && w.started {
+		return nil
+	}
+
+	res := &backend.CallResourceResponse{Status: http.StatusOK, Body: bytes.Clone(w.buffer.Bytes())}
+	if !w.started {
+		res.Headers = w.headers
+		w.started = true
+	}
+	w.buffer.Reset()
+	return w.sender.Send(res)
+}
+
+// exportRecords adds the records to the archive until limit records or maxBytes of bodies are written.
+// The manifest is truncated if any record is left out.
+func exportRecords(records <-chan *reductgo.ReadableRecord, archive recordArchive, limit int, maxBytes int64) (exportManifest, error) {
+	manifest := exportManifest{Records: []exportedRecord{}}
+	var written int64
+	for record := range records {
+		if len(manifest.Records) >= limit || written+record.Size() > maxBytes {
+			manifest.Truncated = true
+			break
+		}
+
+		exported := newExportedRecord(record)
+		exported.File = exportFileName(record)
+		if err := archive.add(record); err != nil {
+			log.DefaultLogger.Error("Failed to export record", "entry", record.Entry(), "time", record.Time(), "error", err)
+			return manifest, err
+		}
+		manifest.Records = append(manifest.Records, exported)
+		written += record.Size()
+	}
+	return manifest, nil
+}
+
+func (d *ReductDatasource) handleExport(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var payload struct {
+		Query  reductQuery `json:"query"`
+		From   int64       `json:"from"`
+		To     int64       `json:"to"`
+		Format string      `json:"format"`
+		Limit  int         `json:"limit"`
+	}
+
+	if err := json.Unmarshal(req.Body, &payload); err != nil || payload.Query.Bucket == "" {
+		log.DefaultLogger.Warn("Missing or invalid query in request")
+		return sendError(sender, http.StatusBadRequest, "missing or invalid 'query' in request")
+	}
+
+	if payload.Format == "" {
+		payload.Format = ExportTar
+	}
+	contentType, ok := exportContentTypes[payload.Format]
+	if !ok {
+		return sendError(sender, http.StatusBadRequest, fmt.Sprintf("unknown export format '%s'", payload.Format))
+	}
+
+	limit := defaultExportRecords
+	if payload.Limit > 0 {
+		limit = min(payload.Limit, maxExportRecords)
+	}
+
+	patterns := payload.Query.Entries
+	if len(patterns) == 0 && payload.Query.Entry != "" {
+		patterns = []string{payload.Query.Entry}
+	}
+
+	// one record more than the limit tells whether the export is complete
+	when, err := applyAdhocFilters(payload.Query.Options.When, payload.Query.AdhocFilters)
+	if err == nil {
+		when, err = withLimit(when, limit+1)
+	}
+	if err != nil {
+		return sendError(sender, http.StatusBadRequest, err.Error())
+	}
+
+	bucket, err := d.reductClient.GetBucket(ctx, payload.Query.Bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get bucket", "bucket", payload.Query.Bucket, "error", err)
+		return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error getting bucket: %v", err))
+	}
+
+	entries, err := bucket.GetEntries(ctx)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to list entries", "error", err)
+		return sendError(sender, http.StatusInternalServerError, "error getting entries")
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range filterEntries(entries, patterns) {
+		names = append(names, entry.Name)
+	}
+	if len(names) == 0 {
+		return sendError(sender, http.StatusNotFound, "no entries match the query")
+	}
+
+	builder := reductgo.NewQueryOptionsBuilder().WithWhen(when)
+	if payload.From > 0 {
+		builder.WithStart(time.UnixMilli(payload.From).UnixMicro())
+	}
+	if payload.To > 0 {
+		builder.WithStop(time.UnixMilli(payload.To).UnixMicro())
+	}
+	options := builder.Build()
+
+	// stops reading the records when the export ends early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result, err := bucket.QueryMany(ctx, names, &options)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to query", "error", err)
+		var apiErr model.APIError
+		errorMsg := "export query failed"
+		if errors.As(err, &apiErr) {
+			errorMsg = apiErr.Message
+		}
+		return sendError(sender, http.StatusInternalServerError, errorMsg)
+	}
+
+	writer := &chunkWriter{
+		sender: sender,
+		headers: map[string][]string{
+			"Content-Type":        {contentType},
+			"Content-Disposition": {fmt.Sprintf("attachment; filename=\"%s-export.%s\"", payload.Query.Bucket, payload.Format)},
+		},
+	}
+	archive, err := newRecordArchive(payload.Format, writer)
+	if err != nil {
+		return sendError(sender, http.StatusBadRequest, err.Error())
+	}
+
+	// the response has started with the first chunk, so failures can only be logged from here on
+	manifest, err := exportRecords(result.Records(), archive, limit, maxExportBytes)
+	if err != nil {
+		return err
+	}
+	if err := archive.close(manifest); err != nil {
+		log.DefaultLogger.Error("Failed to finish export", "error", err)
+		return err
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func newExportRecord(entry string, ts int64, body string, contentType string, labels reductgo.LabelMap) *reductgo.ReadableRecord {
+	return reductgo.NewReadableRecord(entry, ts, int64(len(body)), false, io.NopCloser(strings.NewReader(body)), labels, contentType)
+}
+
+func TestExportFileName(t *testing.T) {
+	assert.Equal(t, "cam/10.json", exportFileName(newExportRecord("cam", 10, "", "application/json", nil)))
+	assert.Equal(t, "cam/10.bin", exportFileName(newExportRecord("cam", 10, "", "", nil)))
+}
+
+func TestTarArchive(t *testing.T) {
+	var out bytes.Buffer
+	archive, err := newRecordArchive(ExportTar, &out)
+	assert.NoError(t, err)
+
+	assert.NoError(t, archive.add(newExportRecord("cam", 10, `{"a":1}`, "application/json", nil)))
+	assert.NoError(t, archive.close(exportManifest{Records: []exportedRecord{{File: "cam/10.json", Entry: "cam", Time: 10}}}))
+
+	reader := tar.NewReader(&out)
+	header, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "cam/10.json", header.Name)
+	body, _ := io.ReadAll(reader)
+	assert.Equal(t, `{"a":1}`, string(body))
+
+	header, err = reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, exportManifestName, header.Name)
+	var manifest exportManifest
+	assert.NoError(t, json.NewDecoder(reader).Decode(&manifest))
+	assert.Equal(t, "cam", manifest.Records[0].Entry)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestZipArchive(t *testing.T) {
+	var out bytes.Buffer
+	archive, err := newRecordArchive(ExportZip, &out)
+	assert.NoError(t, err)
+
+	assert.NoError(t, archive.add(newExportRecord("cam", 10, "hello", "text/plain", nil)))
+	assert.NoError(t, archive.close(exportManifest{Records: []exportedRecord{}}))
+
+	reader, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, reader.File, 2)
+	assert.True(t, strings.HasPrefix(reader.File[0].Name, "cam/10."))
+	assert.Equal(t, exportManifestName, reader.File[1].Name)
+}
+
+func TestNDJSONArchive(t *testing.T) {
+	var out bytes.Buffer
+	archive, err := newRecordArchive(ExportNDJSON, &out)
+	assert.NoError(t, err)
+
+	assert.NoError(t, archive.add(newExportRecord("cam", 10, "hi", "text/plain", reductgo.LabelMap{"k": "v"})))
+	assert.NoError(t, archive.close(exportManifest{}))
+
+	assert.JSONEq(t,
+		`{"entry":"cam","time":10,"size":2,"content_type":"text/plain","labels":{"k":"v"},"body":"aGk="}`,
+		out.String())
+
+	_, err = newRecordArchive("rar", &out)
+	assert.EqualError(t, err, "unknown export format 'rar'")
+}
+
+func TestExportRecords_Truncated(t *testing.T) {
+	newRecords := func() chan *reductgo.ReadableRecord {
+		records := make(chan *reductgo.ReadableRecord, 3)
+		for i := int64(1); i <= 3; i++ {
+			records <- newExportRecord("cam", i, "hi", "text/plain", nil)
+		}
+		close(records)
+		return records
+	}
+
+	var out bytes.Buffer
+	archive, _ := newRecordArchive(ExportNDJSON, &out)
+	manifest, err := exportRecords(newRecords(), archive, 3, maxExportBytes)
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Records, 3)
+	assert.False(t, manifest.Truncated)
+
+	manifest, err = exportRecords(newRecords(), archive, 2, maxExportBytes)
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Records, 2)
+	assert.True(t, manifest.Truncated)
+
+	manifest, err = exportRecords(newRecords(), archive, 3, 5)
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Records, 2)
+	assert.True(t, manifest.Truncated)
+
+	out.Reset()
+	assert.NoError(t, archive.close(manifest))
+	assert.JSONEq(t, `{"truncated":true}`, out.String())
+}
+
+func TestChunkWriter(t *testing.T) {
+	var responses []*backend.CallResourceResponse
+	writer := &chunkWriter{
+		sender: backend.CallResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+			responses = append(responses, res)
+			return nil
+		}),
+		headers: map[string][]string{"Content-Type": {"application/x-tar"}},
+	}
+
+	_, err := writer.Write(make([]byte, exportChunkSize+1))
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("tail"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Flush())
+	assert.NoError(t, writer.Flush())
+
+	assert.Len(t, responses, 2)
+	assert.Equal(t, http.StatusOK, responses[0].Status)
+	assert.Equal(t, []string{"application/x-tar"}, responses[0].Headers["Content-Type"])
+	assert.Len(t, responses[0].Body, exportChunkSize+1)
+	assert.Nil(t, responses[1].Headers)
+	assert.Equal(t, "tail", string(responses[1].Body))
+}
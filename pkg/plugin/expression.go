@@ -0,0 +1,492 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+)
+
+// maxExpressionLength keeps expressions small enough to be evaluated for every record.
+const maxExpressionLength = 1024
+
+// expressionOptions computes a new series per record, e.g. {"name": "$.power", "expr": "$.voltage * $.current"}.
+// Labels are referenced with "&name" and JSON paths of the content with "$.path". Names containing a minus
+// must be separated from a subtraction by spaces.
+type expressionOptions struct {
+	Name string `json:"name"`
+	Expr string `json:"expr"`
+}
+
+// expression is a compiled arithmetic expression.
+type expression struct {
+	name string
+	root exprNode
+	// usesContent is true if the expression references the record content.
+	usesContent bool
+}
+
+// exprEnv holds the values an expression can reference.
+type exprEnv struct {
+	labels  reductgo.LabelMap
+	content map[string]any
+}
+
+type exprNode interface {
+	eval(env exprEnv) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(exprEnv) (float64, error) {
+	return float64(n), nil
+}
+
+// refNode references a label or, if content is set, a JSON path.
+type refNode struct {
+	name    string
+	content bool
+}
+
+func (n refNode) eval(env exprEnv) (float64, error) {
+	var value any
+	var ok bool
+	if n.content {
+		value, ok = env.content[n.name]
+	} else {
+		value, ok = env.labels[n.name]
+	}
+	if !ok {
+		return 0, fmt.Errorf("'%s' not found", n.ref())
+	}
+
+	if f, ok := value.(float64); ok {
+		return f, nil
+	}
+	switch v := parseValue(fmt.Sprintf("%v", value)).(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("'%s' is not a number", n.ref())
+	}
+}
+
+func (n refNode) ref() string {
+	if n.content {
+		return n.name
+	}
+	return "&" + n.name
+}
+
+type unaryNode struct {
+	operand exprNode
+}
+
+func (n unaryNode) eval(env exprEnv) (float64, error) {
+	v, err := n.operand.eval(env)
+	return -v, err
+}
+
+type binaryNode struct {
+	op          byte
+	left, right exprNode
+}
+
+func (n binaryNode) eval(env exprEnv) (float64, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	case '/':
+		if right == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return left / right, nil
+	default:
+		if right == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return math.Mod(left, right), nil
+	}
+}
+
+// exprFunction is a function callable from an expression with a fixed number of arguments.
+type exprFunction struct {
+	arity int
+	call  func(args []float64) float64
+}
+
+var exprFunctions = map[string]exprFunction{
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"round": {1, func(a []float64) float64 { return math.Round(a[0]) }},
+	"floor": {1, func(a []float64) float64 { return math.Floor(a[0]) }},
+	"ceil":  {1, func(a []float64) float64 { return math.Ceil(a[0]) }},
+	"log":   {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"min":   {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":   {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+	"pow":   {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+}
+
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+func (n callNode) eval(env exprEnv) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return exprFunctions[n.name].call(args), nil
+}
+
+// evaluate computes the expression for a record. NaN and infinite results are errors.
+func (e *expression) evaluate(env exprEnv) (float64, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return 0, fmt.Errorf("expression %s: %w", e.name, err)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("expression %s: result is not a finite number", e.name)
+	}
+	return v, nil
+}
+
+// compileExpressions parses the expressions of a query.
+func compileExpressions(options []expressionOptions) ([]*expression, error) {
+	result := make([]*expression, 0, len(options))
+	names := make(map[string]bool, len(options))
+	for _, o := range options {
+		e, err := compileExpression(o)
+		if err != nil {
+			return nil, err
+		}
+		if names[e.name] {
+			return nil, fmt.Errorf("duplicate expression name %s", e.name)
+		}
+		names[e.name] = true
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+func compileExpression(o expressionOptions) (*expression, error) {
+	if strings.TrimSpace(o.Name) == "" {
+		return nil, errors.New("expression without a name")
+	}
+	if len(o.Expr) > maxExpressionLength {
+		return nil, fmt.Errorf("expression %s is longer than %d characters", o.Name, maxExpressionLength)
+	}
+
+	p := &exprParser{input: o.Expr}
+	root, err := p.parseSum()
+	if err == nil {
+		p.skipSpaces()
+		if p.pos < len(p.input) {
+			err = fmt.Errorf("unexpected '%c' at %d", p.input[p.pos], p.pos)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %s: %w", o.Name, err)
+	}
+	return &expression{name: o.Name, root: root, usesContent: p.usesContent}, nil
+}
+
+// exprParser is a recursive descent parser of the grammar:
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/" | "%") unary }
+//	unary   = "-" unary | primary
+//	primary = number | "&" label | "$" path | function "(" sum { "," sum } ")" | "(" sum ")"
+type exprParser struct {
+	input       string
+	pos         int
+	usesContent bool
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character or 0 at the end of the input.
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/' || op == '%'; op = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	c := p.peek()
+	start := p.pos
+	switch {
+	case c == 0:
+		return nil, errors.New("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' at %d", p.pos)
+		}
+		p.pos++
+		return node, nil
+	case c == '&':
+		p.pos++
+		name := p.scan(isLabelChar)
+		if name == "" {
+			return nil, fmt.Errorf("missing label name at %d", start)
+		}
+		return refNode{name: name}, nil
+	case c == '$':
+		p.pos++
+		path := "$" + p.scan(isPathChar)
+		if !strings.HasPrefix(path, "$.") || len(path) < 3 {
+			return nil, fmt.Errorf("invalid JSON path at %d", start)
+		}
+		p.usesContent = true
+		return refNode{name: path, content: true}, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		literal := p.scanNumber()
+		v, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at %d", literal, start)
+		}
+		return numberNode(v), nil
+	case unicode.IsLetter(rune(c)):
+		name := p.scan(func(c byte) bool { return unicode.IsLetter(rune(c)) })
+		return p.parseCall(name, start)
+	default:
+		return nil, fmt.Errorf("unexpected '%c' at %d", c, start)
+	}
+}
+
+func (p *exprParser) parseCall(name string, start int) (exprNode, error) {
+	function, ok := exprFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s' at %d", name, start)
+	}
+	if p.peek() != '(' {
+		return nil, fmt.Errorf("missing '(' after %s", name)
+	}
+	p.pos++
+
+	var args []exprNode
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		c := p.peek()
+		p.pos++
+		if c == ')' {
+			break
+		}
+		if c != ',' {
+			return nil, fmt.Errorf("expected ',' or ')' in %s", name)
+		}
+	}
+
+	if len(args) != function.arity {
+		return nil, fmt.Errorf("function %s expects %d arguments, got %d", name, function.arity, len(args))
+	}
+	return callNode{name: name, args: args}, nil
+}
+
+// scan consumes the characters accepted by the predicate.
+func (p *exprParser) scan(accept func(c byte) bool) string {
+	start := p.pos
+	for p.pos < len(p.input) && accept(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// scanNumber reads a number literal, including the sign of an exponent like in 1e-3.
+func (p *exprParser) scanNumber() string {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.input) && isNumberChar(p.input[p.pos-1], p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func isLabelChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+func isPathChar(c byte) bool {
+	return c == '_' || c == '.' || c == '[' || c == ']' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+func isNumberChar(prev, c byte) bool {
+	if c == '+' || c == '-' {
+		return prev == 'e' || prev == 'E'
+	}
+	return c == '.' || c == 'e' || c == 'E' || unicode.IsDigit(rune(c))
+}
+
+// expressionsUseContent reports whether any expression references the record content.
+func expressionsUseContent(expressions []*expression) bool {
+	for _, e := range expressions {
+		if e.usesContent {
+			return true
+		}
+	}
+	return false
+}
+
+// expressionFailure counts the records an expression couldn't be computed for.
+type expressionFailure struct {
+	count int
+	first error
+}
+
+// expressionCollision returns an error if an expression is named like a label or a JSON path of the record
+// which is a series of its own, because both series would share a frame.
+func expressionCollision(
+	expressions []*expression,
+	record *reductgo.ReadableRecord,
+	withLabels, withContent bool,
+	content map[string]any,
+	groupBy []string,
+) error {
+	for _, e := range expressions {
+		if _, ok := record.Labels()[e.name]; ok && withLabels && !slices.Contains(groupBy, e.name) {
+			return fmt.Errorf("expression %s is named like a label of entry %s", e.name, record.Entry())
+		}
+		if _, ok := content[e.name]; ok && withContent {
+			return fmt.Errorf("expression %s is named like a JSON path of entry %s", e.name, record.Entry())
+		}
+	}
+	return nil
+}
+
+// processExpressions appends the result of every expression for the record to the frame "<entry>/<name>".
+func processExpressions(
+	frames map[string]*data.Frame,
+	failures map[string]*expressionFailure,
+	record *reductgo.ReadableRecord,
+	content map[string]any,
+	expressions []*expression,
+	groupBy []string,
+) {
+	if len(expressions) == 0 {
+		return
+	}
+
+	group := groupLabels(record, groupBy)
+	env := exprEnv{labels: record.Labels(), content: content}
+	for _, e := range expressions {
+		key := seriesKey(record.Entry()+"/"+e.name, group)
+		v, err := e.evaluate(env)
+		if err != nil {
+			failure, ok := failures[key]
+			if !ok {
+				failure = &expressionFailure{first: err}
+				failures[key] = failure
+			}
+			failure.count++
+			continue
+		}
+		appendValue(frames, key, group, record, v)
+	}
+}
+
+// reportExpressionFailures adds a notice to the series of the failed expressions. A series without any
+// value gets an empty frame so that the notice is shown.
+func reportExpressionFailures(frames map[string]*data.Frame, failures map[string]*expressionFailure) {
+	for key, failure := range failures {
+		frame, ok := frames[key]
+		if !ok {
+			frame = data.NewFrame(key,
+				data.NewField("time", nil, []time.Time{}),
+				data.NewField("value", nil, []float64{}),
+			)
+			frame.Meta = &data.FrameMeta{Type: data.FrameTypeTimeSeriesWide}
+			frames[key] = frame
+		}
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Failed to compute %d values: %v", failure.count, failure.first),
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileExpression(t *testing.T) {
+	env := exprEnv{
+		labels:  reductgo.LabelMap{"temp_f": "212", "mode": "auto", "battery-level": "50"},
+		content: map[string]any{"$.voltage": 12.0, "$.current": 2.5, "$.motors[0].rpm": 100.0},
+	}
+
+	cases := map[string]float64{
+		"$.voltage * $.current":        30,
+		"(&temp_f - 32) * 5 / 9":       100,
+		"-&battery-level + 2 * 3":      -44,
+		"max($.motors[0].rpm, 50) % 7": 2,
+		"round(sqrt(pow(3, 2) + 16))":  5,
+		"1.5e2 - abs(-50)":             100,
+		"1e-3 * 1000":                  1,
+		"2.5E+2 - 2.5e2":               0,
+		"1e3-1E3":                      0,
+		"2-1":                          1,
+	}
+	for expr, expected := range cases {
+		e, err := compileExpression(expressionOptions{Name: "x", Expr: expr})
+		assert.NoError(t, err, expr)
+		v, err := e.evaluate(env)
+		assert.NoError(t, err, expr)
+		assert.InDelta(t, expected, v, 1e-9, expr)
+	}
+
+	e, _ := compileExpression(expressionOptions{Name: "x", Expr: "&temp_f * 2"})
+	assert.False(t, e.usesContent)
+	e, _ = compileExpression(expressionOptions{Name: "x", Expr: "$.voltage * 2"})
+	assert.True(t, e.usesContent)
+}
+
+func TestCompileExpressionErrors(t *testing.T) {
+	cases := map[string]string{
+		"1 +":      "expression x: unexpected end of expression",
+		"(1 + 2":   "expression x: missing ')' at 6",
+		"exec(1)":  "expression x: unknown function 'exec' at 0",
+		"min(1)":   "expression x: function min expects 2 arguments, got 1",
+		"& + 1":    "expression x: missing label name at 0",
+		"$voltage": "expression x: invalid JSON path at 0",
+		"1 2":      "expression x: unexpected '2' at 2",
+		"&a == &b": "expression x: unexpected '=' at 3",
+		"1e+":      "expression x: invalid number '1e+' at 0",
+	}
+	for expr, expected := range cases {
+		_, err := compileExpression(expressionOptions{Name: "x", Expr: expr})
+		assert.EqualError(t, err, expected, expr)
+	}
+
+	_, err := compileExpressions([]expressionOptions{{Expr: "1"}})
+	assert.EqualError(t, err, "expression without a name")
+
+	_, err = compileExpressions([]expressionOptions{{Name: "x", Expr: "1"}, {Name: "x", Expr: "2"}})
+	assert.EqualError(t, err, "duplicate expression name x")
+}
+
+func TestEvaluateExpressionErrors(t *testing.T) {
+	env := exprEnv{labels: reductgo.LabelMap{"mode": "auto", "zero": "0"}}
+
+	cases := map[string]string{
+		"&mode + 1":  "expression x: '&mode' is not a number",
+		"&speed + 1": "expression x: '&speed' not found",
+		"1 / &zero":  "expression x: division by zero",
+		"log(&zero)": "expression x: result is not a finite number",
+	}
+	for expr, expected := range cases {
+		e, err := compileExpression(expressionOptions{Name: "x", Expr: expr})
+		assert.NoError(t, err, expr)
+		_, err = e.evaluate(env)
+		assert.EqualError(t, err, expected, expr)
+	}
+}
+
+func TestGetFramesWithExpressions(t *testing.T) {
+	expressions, err := compileExpressions([]expressionOptions{
+		{Name: "$.power", Expr: "$.voltage * $.current"},
+		{Name: "temp_c", Expr: "(&temp_f - 32) / 1.8"},
+	})
+	assert.NoError(t, err)
+
+	records := make(chan *reductgo.ReadableRecord, 2)
+	records <- reductgo.NewReadableRecord("motor", 1, 0, false,
+		io.NopCloser(strings.NewReader(`{"voltage": 12, "current": 2}`)), reductgo.LabelMap{"temp_f": "212"}, "application/json")
+	records <- reductgo.NewReadableRecord("motor", 2, 0, true,
+		io.NopCloser(strings.NewReader(`{"voltage": 10}`)), reductgo.LabelMap{"temp_f": "32"}, "application/json")
+	close(records)
+
+	frames, err := getFrames(records, ModeLabelOnly, nil, expressions)
+	require.NoError(t, err)
+
+	byName := map[string]*data.Frame{}
+	for _, frame := range frames {
+		byName[frame.Name] = frame
+	}
+
+	power := byName["motor/$.power"]
+	assert.Equal(t, data.NewField("value", nil, []float64{24}), power.Fields[1])
+	assert.Len(t, power.Meta.Notices, 1)
+	assert.Equal(t, "Failed to compute 1 values: expression $.power: '$.current' not found", power.Meta.Notices[0].Text)
+
+	assert.Equal(t, data.NewField("value", nil, []float64{100, 0}), byName["motor/temp_c"].Fields[1])
+	assert.Contains(t, byName, "motor/temp_f")
+	assert.NotContains(t, byName, "motor/$.voltage")
+}
+
+func TestGetFramesWithExpressions_NameCollision(t *testing.T) {
+	expressions, err := compileExpressions([]expressionOptions{{Name: "temp", Expr: "&temp * 1.8 + 32"}})
+	require.NoError(t, err)
+
+	newRecords := func() chan *reductgo.ReadableRecord {
+		records := make(chan *reductgo.ReadableRecord, 2)
+		records <- reductgo.NewReadableRecord("sensor", 1, 0, false,
+			io.NopCloser(strings.NewReader(`{"temp": 20}`)), reductgo.LabelMap{"temp": "20"}, "application/json")
+		records <- reductgo.NewReadableRecord("sensor", 2, 0, true,
+			io.NopCloser(strings.NewReader(`{"temp": 21}`)), reductgo.LabelMap{"temp": "21"}, "application/json")
+		close(records)
+		return records
+	}
+
+	records := newRecords()
+	_, err = getFrames(records, ModeLabelOnly, nil, expressions)
+	assert.EqualError(t, err, "expression temp is named like a label of entry sensor")
+	assert.Empty(t, records, "the records are drained")
+
+	// the label isn't a series of its own without labels
+	frames, err := getFrames(newRecords(), ModeContentOnly, nil, expressions)
+	require.NoError(t, err)
+	assert.Len(t, frames, 2)
+
+	expressions, err = compileExpressions([]expressionOptions{{Name: "$.temp", Expr: "&temp * 1.8 + 32"}})
+	require.NoError(t, err)
+	_, err = getFrames(newRecords(), ModeContentOnly, nil, expressions)
+	assert.EqualError(t, err, "expression $.temp is named like a JSON path of entry sensor")
+}
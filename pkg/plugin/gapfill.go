@@ -0,0 +1,190 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Gap fill modes.
+const (
+	// GapFillNull inserts a null after the last point before a gap so that panels break the line.
+	GapFillNull = "null"
+	// GapFillPrevious repeats the last value before a gap on the interval grid.
+	GapFillPrevious = "previous"
+	// GapFillZero fills a gap with zeros on the interval grid.
+	GapFillZero = "zero"
+	// GapFillLinear interpolates between the points around a gap on the interval grid.
+	GapFillLinear = "linear"
+)
+
+// maxGapFillPoints limits the points inserted into a frame.
+const maxGapFillPoints = maxStatsWindows
+
+// gapFillOptions selects how gaps longer than Staleness are handled. Staleness defaults to the query interval.
+type gapFillOptions struct {
+	Mode      string `json:"mode,omitempty"`
+	Staleness string `json:"staleness,omitempty"`
+}
+
+// staleness returns the longest distance between two points which isn't a gap.
+func (o gapFillOptions) staleness(interval time.Duration) (time.Duration, error) {
+	if o.Staleness == "" {
+		return interval, nil
+	}
+
+	staleness, err := time.ParseDuration(o.Staleness)
+	if err != nil || staleness <= 0 {
+		return 0, fmt.Errorf("invalid gap fill staleness '%s'", o.Staleness)
+	}
+	return staleness, nil
+}
+
+// validate checks the mode and the staleness.
+func (o gapFillOptions) validate() error {
+	switch o.Mode {
+	case "", GapFillNull, GapFillPrevious, GapFillZero, GapFillLinear:
+	default:
+		return fmt.Errorf("unknown gap fill mode: %s", o.Mode)
+	}
+	_, err := o.staleness(time.Second)
+	return err
+}
+
+// fillGaps inserts points into the gaps of the time series frames. All value fields of a frame share
+// its rows, so wide frames are filled as a whole. Except in the null mode, null values are filled as well.
+// The null mode inserts its null once the point before the gap is stale; the other modes fill a grid
+// of the interval, narrowed to the staleness if that is shorter. Frames with non-numeric fields are left as they are.
+func fillGaps(frames []*data.Frame, mode string, staleness, step time.Duration) {
+	if step <= 0 || step > staleness {
+		step = staleness
+	}
+
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 || frame.Fields[0].Type() != data.FieldTypeTime || frame.Fields[0].Len() == 0 {
+			continue
+		}
+		if !allNumeric(frame.Fields[1:]) {
+			continue
+		}
+		fillFrameGaps(frame, mode, staleness, step)
+	}
+}
+
+func allNumeric(fields []*data.Field) bool {
+	for _, field := range fields {
+		if !field.Type().Numeric() {
+			return false
+		}
+	}
+	return true
+}
+
+func fillFrameGaps(frame *data.Frame, mode string, staleness, step time.Duration) {
+	rows := frame.Fields[0].Len()
+	columns := make([][]*float64, len(frame.Fields)-1)
+	for c, field := range frame.Fields[1:] {
+		columns[c] = make([]*float64, rows)
+		for r := range rows {
+			if v, err := field.NullableFloatAt(r); err == nil {
+				columns[c][r] = v
+			}
+		}
+	}
+
+	times := []time.Time{frame.Fields[0].At(0).(time.Time)}
+	filled := make([][]*float64, len(columns))
+	for c := range columns {
+		filled[c] = []*float64{columns[c][0]}
+	}
+
+	inserted := 0
+	for r := 1; r < rows; r++ {
+		prev, next := frame.Fields[0].At(r-1).(time.Time), frame.Fields[0].At(r).(time.Time)
+		if next.Sub(prev) > staleness {
+			// a single null is enough to break the line
+			if mode == GapFillNull || mode == "" {
+				if inserted < maxGapFillPoints {
+					times = append(times, prev.Add(staleness))
+					for c := range columns {
+						filled[c] = append(filled[c], nil)
+					}
+					inserted++
+				}
+			} else {
+				for t := prev.Add(step); t.Before(next) && inserted < maxGapFillPoints; t = t.Add(step) {
+					times = append(times, t)
+					for c := range columns {
+						filled[c] = append(filled[c], gapValue(mode, prev, next, t, columns[c][r-1], columns[c][r]))
+					}
+					inserted++
+				}
+			}
+		}
+
+		times = append(times, next)
+		for c := range columns {
+			filled[c] = append(filled[c], columns[c][r])
+		}
+	}
+
+	if mode != GapFillNull {
+		for c := range filled {
+			fillNulls(mode, times, filled[c])
+		}
+	}
+
+	fields := []*data.Field{data.NewField(frame.Fields[0].Name, frame.Fields[0].Labels, times)}
+	fields[0].Config = frame.Fields[0].Config
+	for c, field := range frame.Fields[1:] {
+		f := data.NewField(field.Name, field.Labels, filled[c])
+		f.Config = field.Config
+		fields = append(fields, f)
+	}
+	frame.Fields = fields
+}
+
+// fillNulls replaces the null values of a column, e.g. the grid times of an aligned frame without a match.
+// Nulls before the first value have no previous value and can't be interpolated, nor can the ones after the last.
+func fillNulls(mode string, times []time.Time, values []*float64) {
+	last := -1
+	for i, v := range values {
+		if v == nil {
+			if mode == GapFillPrevious && last >= 0 {
+				values[i] = values[last]
+			} else if mode == GapFillZero {
+				zero := 0.0
+				values[i] = &zero
+			}
+			continue
+		}
+
+		if mode == GapFillLinear && last >= 0 {
+			for k := last + 1; k < i; k++ {
+				values[k] = gapValue(mode, times[last], times[i], times[k], values[last], v)
+			}
+		}
+		last = i
+	}
+}
+
+// gapValue returns the value inserted at t between the points (prev, before) and (next, after).
+func gapValue(mode string, prev, next, t time.Time, before, after *float64) *float64 {
+	switch mode {
+	case GapFillPrevious:
+		return before
+	case GapFillZero:
+		zero := 0.0
+		return &zero
+	case GapFillLinear:
+		if before == nil || after == nil {
+			return nil
+		}
+		ratio := float64(t.Sub(prev)) / float64(next.Sub(prev))
+		v := *before + (*after-*before)*ratio
+		return &v
+	default:
+		return nil
+	}
+}
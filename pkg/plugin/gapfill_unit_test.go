@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGapFillValidate(t *testing.T) {
+	assert.NoError(t, gapFillOptions{}.validate())
+	assert.NoError(t, gapFillOptions{Mode: GapFillLinear, Staleness: "5m"}.validate())
+	assert.EqualError(t, gapFillOptions{Mode: "spline"}.validate(), "unknown gap fill mode: spline")
+	assert.EqualError(t, gapFillOptions{Mode: GapFillZero, Staleness: "soon"}.validate(), "invalid gap fill staleness 'soon'")
+
+	staleness, err := gapFillOptions{}.staleness(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, staleness)
+}
+
+func newGapFrame() *data.Frame {
+	return data.NewFrame("robot/battery",
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(10, 0), time.Unix(40, 0)}),
+		data.NewField("min", nil, []float64{1, 2, 5}),
+		data.NewField("max", nil, []int64{10, 20, 50}),
+	)
+}
+
+func TestFillGaps(t *testing.T) {
+	sec := func(s int64) time.Time { return time.Unix(s, 0) }
+	times := []time.Time{sec(0), sec(10), sec(20), sec(30), sec(40)}
+
+	frame := newGapFrame()
+	fillGaps([]*data.Frame{frame}, GapFillNull, 15*time.Second, 10*time.Second)
+	assert.Equal(t, data.NewField("time", nil, []time.Time{sec(0), sec(10), sec(25), sec(40)}), frame.Fields[0])
+	assert.Equal(t, data.NewField("min", nil, floats(1.0, 2.0, nil, 5.0)), frame.Fields[1])
+
+	frame = newGapFrame()
+	fillGaps([]*data.Frame{frame}, GapFillPrevious, 15*time.Second, 10*time.Second)
+	assert.Equal(t, data.NewField("time", nil, times), frame.Fields[0])
+	assert.Equal(t, data.NewField("max", nil, floats(10.0, 20.0, 20.0, 20.0, 50.0)), frame.Fields[2])
+
+	frame = newGapFrame()
+	fillGaps([]*data.Frame{frame}, GapFillZero, 15*time.Second, 10*time.Second)
+	assert.Equal(t, data.NewField("min", nil, floats(1.0, 2.0, 0.0, 0.0, 5.0)), frame.Fields[1])
+
+	frame = newGapFrame()
+	fillGaps([]*data.Frame{frame}, GapFillLinear, 15*time.Second, 10*time.Second)
+	assert.Equal(t, data.NewField("min", nil, floats(1.0, 2.0, 3.0, 4.0, 5.0)), frame.Fields[1])
+	assert.Equal(t, data.NewField("max", nil, floats(10.0, 20.0, 30.0, 40.0, 50.0)), frame.Fields[2])
+}
+
+func TestFillGapsStalenessShorterThanInterval(t *testing.T) {
+	newFrame := func() *data.Frame {
+		return data.NewFrame("robot/speed",
+			data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(60, 0)}),
+			data.NewField("value", nil, []float64{1, 7}),
+		)
+	}
+
+	frame := newFrame()
+	fillGaps([]*data.Frame{frame}, GapFillNull, 10*time.Second, time.Minute)
+	assert.Equal(t, data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(10, 0), time.Unix(60, 0)}), frame.Fields[0])
+	assert.Equal(t, data.NewField("value", nil, floats(1.0, nil, 7.0)), frame.Fields[1])
+
+	frame = newFrame()
+	fillGaps([]*data.Frame{frame}, GapFillLinear, 20*time.Second, time.Minute)
+	assert.Equal(t, data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(20, 0), time.Unix(40, 0), time.Unix(60, 0)}), frame.Fields[0])
+	assert.Equal(t, data.NewField("value", nil, floats(1.0, 3.0, 5.0, 7.0)), frame.Fields[1])
+}
+
+func TestFillGapsSkipsNonNumericFrames(t *testing.T) {
+	frame := data.NewFrame("robot/state",
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(100, 0)}),
+		data.NewField("value", nil, []string{"a", "b"}),
+	)
+	fillGaps([]*data.Frame{frame}, GapFillNull, time.Second, time.Second)
+	assert.Equal(t, 2, frame.Fields[0].Len())
+}
+
+func TestFillNulls(t *testing.T) {
+	times := []time.Time{time.Unix(0, 0), time.Unix(1, 0), time.Unix(2, 0), time.Unix(4, 0), time.Unix(5, 0)}
+
+	values := floats(nil, 1.0, nil, 4.0, nil)
+	fillNulls(GapFillPrevious, times, values)
+	assert.Equal(t, floats(nil, 1.0, 1.0, 4.0, 4.0), values)
+
+	values = floats(nil, 1.0, nil, 4.0, nil)
+	fillNulls(GapFillZero, times, values)
+	assert.Equal(t, floats(0.0, 1.0, 0.0, 4.0, 0.0), values)
+
+	values = floats(nil, 1.0, nil, 4.0, nil)
+	fillNulls(GapFillLinear, times, values)
+	assert.Equal(t, floats(nil, 1.0, 2.0, 4.0, nil), values)
+}
@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	model "github.com/reductstore/reduct-go/model"
+)
+
+// queryInventory returns a table with the metadata of every entry in the bucket matching the query patterns.
+func (d *ReductDatasource) queryInventory(ctx context.Context, bucketName string, patterns []string) backend.DataResponse {
+	bucket, err := d.reductClient.GetBucket(ctx, bucketName)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get bucket", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	entries, err := bucket.GetEntries(ctx)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to list entries", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	return backend.DataResponse{
+		Frames: []*data.Frame{getInventoryFrame(filterEntries(entries, patterns), time.Now())},
+	}
+}
+
+// getInventoryFrame builds a table frame with one row per entry. Staleness is the time in seconds
+// since the latest record of the entry relative to now.
+func getInventoryFrame(entries []model.EntryInfo, now time.Time) *data.Frame {
+	sorted := append([]model.EntryInfo(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	names := make([]string, len(sorted))
+	recordCounts := make([]int64, len(sorted))
+	blockCounts := make([]int64, len(sorted))
+	sizes := make([]int64, len(sorted))
+	oldest := make([]*time.Time, len(sorted))
+	latest := make([]*time.Time, len(sorted))
+	staleness := make([]*float64, len(sorted))
+
+	for i, entry := range sorted {
+		names[i] = entry.Name
+		recordCounts[i] = entry.RecordCount
+		blockCounts[i] = entry.BlockCount
+		sizes[i] = entry.Size
+
+		// empty entries have no records to report
+		if entry.RecordCount == 0 {
+			continue
+		}
+
+		oldestTime := time.UnixMicro(entry.OldestRecord)
+		latestTime := time.UnixMicro(entry.LatestRecord)
+		age := now.Sub(latestTime).Seconds()
+		oldest[i] = &oldestTime
+		latest[i] = &latestTime
+		staleness[i] = &age
+	}
+
+	frame := data.NewFrame("inventory",
+		data.NewField("entry", nil, names),
+		data.NewField("record_count", nil, recordCounts),
+		data.NewField("block_count", nil, blockCounts),
+		newUnitField("size", "bytes", sizes),
+		data.NewField("oldest_record", nil, oldest),
+		data.NewField("latest_record", nil, latest),
+		newUnitField("staleness", "s", staleness),
+	)
+	frame.Meta = &data.FrameMeta{
+		Type: data.FrameTypeTable,
+	}
+	return frame
+}
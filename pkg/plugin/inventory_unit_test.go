@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	model "github.com/reductstore/reduct-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInventoryFrame(t *testing.T) {
+	now := time.Unix(1000, 0)
+	frame := getInventoryFrame([]model.EntryInfo{
+		{Name: "robot-2"},
+		{
+			Name:         "robot-1",
+			RecordCount:  10,
+			BlockCount:   2,
+			Size:         2048,
+			OldestRecord: time.Unix(100, 0).UnixMicro(),
+			LatestRecord: time.Unix(940, 0).UnixMicro(),
+		},
+	}, now)
+
+	require.Equal(t, 2, frame.Rows())
+	assert.Equal(t, data.FrameTypeTable, frame.Meta.Type)
+
+	row := frame.RowCopy(0)
+	assert.Equal(t, "robot-1", row[0])
+	assert.Equal(t, int64(10), row[1])
+	assert.Equal(t, int64(2), row[2])
+	assert.Equal(t, int64(2048), row[3])
+	assert.Equal(t, time.Unix(100, 0), *row[4].(*time.Time))
+	assert.Equal(t, time.Unix(940, 0), *row[5].(*time.Time))
+	assert.Equal(t, 60.0, *row[6].(*float64))
+
+	row = frame.RowCopy(1)
+	assert.Equal(t, "robot-2", row[0])
+	assert.Nil(t, row[5])
+	assert.Nil(t, row[6])
+}
@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	reductgo "github.com/reductstore/reduct-go"
+)
+
+const (
+	// defaultLabelWindow is the sampled time window when the request doesn't set one.
+	defaultLabelWindow = 24 * time.Hour
+	// maxLabelExamples is the number of distinct example values returned per label.
+	maxLabelExamples = 5
+)
+
+// Label types reported by the schema discovery. They follow the types produced by parseValue.
+const (
+	LabelTypeInt    = "int"
+	LabelTypeFloat  = "float"
+	LabelTypeBool   = "bool"
+	LabelTypeString = "string"
+)
+
+// labelSchema describes a label key observed in the sampled records.
+type labelSchema struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Count    int64    `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// labelTypeOf returns the label type of a value as parseValue would convert it.
+func labelTypeOf(str string) string {
+	switch parseValue(str).(type) {
+	case int64:
+		return LabelTypeInt
+	case float64:
+		return LabelTypeFloat
+	case bool:
+		return LabelTypeBool
+	default:
+		return LabelTypeString
+	}
+}
+
+// mergeLabelTypes returns a type able to hold values of both types. Integers widen to floats,
+// every other mix falls back to strings.
+func mergeLabelTypes(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case (a == LabelTypeInt && b == LabelTypeFloat) || (a == LabelTypeFloat && b == LabelTypeInt):
+		return LabelTypeFloat
+	default:
+		return LabelTypeString
+	}
+}
+
+// inferLabelSchema collects the label keys of the records with their type, count and examples.
+func inferLabelSchema(records <-chan *reductgo.ReadableRecord) []labelSchema {
+	schemas := make(map[string]*labelSchema)
+	for record := range records {
+		for key, value := range record.Labels() {
+			str := fmt.Sprintf("%v", value)
+
+			schema, ok := schemas[key]
+			if !ok {
+				schema = &labelSchema{Name: key, Examples: []string{}}
+				schemas[key] = schema
+			}
+			schema.Count++
+			schema.Type = mergeLabelTypes(schema.Type, labelTypeOf(str))
+			if len(schema.Examples) < maxLabelExamples && !slices.Contains(schema.Examples, str) {
+				schema.Examples = append(schema.Examples, str)
+			}
+		}
+	}
+
+	result := make([]labelSchema, 0, len(schemas))
+	for _, key := range sortedKeys(schemas) {
+		result = append(result, *schemas[key])
+	}
+	return result
+}
+
+// labelWindow resolves the sampled time range. An explicit start wins over the window,
+// which is a duration such as "1h" ending at stop or now.
+func labelWindow(payload labelSampleRequest, now time.Time) (time.Time, time.Time, error) {
+	from, to := payload.timeRange()
+	if !from.IsZero() {
+		return from, to, nil
+	}
+
+	window := defaultLabelWindow
+	if payload.Window != "" {
+		parsed, err := time.ParseDuration(payload.Window)
+		if err != nil || parsed <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid window '%s'", payload.Window)
+		}
+		window = parsed
+	}
+
+	if to.IsZero() {
+		to = now
+	}
+	return to.Add(-window), to, nil
+}
+
+func (d *ReductDatasource) handleListLabels(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var payload labelSampleRequest
+	if err := json.Unmarshal(req.Body, &payload); err != nil || payload.Bucket == "" {
+		log.DefaultLogger.Warn("Missing or invalid bucket in request")
+		return sendError(sender, http.StatusBadRequest, "missing or invalid 'bucket' in request")
+	}
+
+	from, to, err := labelWindow(payload, time.Now())
+	if err != nil {
+		return sendError(sender, http.StatusBadRequest, err.Error())
+	}
+
+	limit := labelKeySampleSize
+	if payload.Limit > 0 {
+		limit = payload.Limit
+	}
+
+	records, err := d.sampleRecords(ctx, payload.Bucket, payload.Entries, nil, from, to, limit)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to sample labels", "bucket", payload.Bucket, "error", err)
+		return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error sampling labels: %v", err))
+	}
+
+	return sendJSON(sender, http.StatusOK, inferLabelSchema(records))
+}
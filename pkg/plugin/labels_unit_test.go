@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferLabelSchema(t *testing.T) {
+	records := make(chan *reductgo.ReadableRecord, 3)
+	records <- newHeadRecord("a", 1, 0, reductgo.LabelMap{"count": "1", "ratio": "1", "ok": "true", "robot": "r1"})
+	records <- newHeadRecord("a", 2, 0, reductgo.LabelMap{"count": "2", "ratio": "0.5", "ok": "1", "robot": "r1"})
+	records <- newHeadRecord("b", 3, 0, reductgo.LabelMap{"count": "3", "robot": "r2"})
+	close(records)
+
+	schema := inferLabelSchema(records)
+	require.Len(t, schema, 4)
+
+	assert.Equal(t, labelSchema{Name: "count", Type: LabelTypeInt, Count: 3, Examples: []string{"1", "2", "3"}}, schema[0])
+	assert.Equal(t, labelSchema{Name: "ok", Type: LabelTypeString, Count: 2, Examples: []string{"true", "1"}}, schema[1])
+	assert.Equal(t, labelSchema{Name: "ratio", Type: LabelTypeFloat, Count: 2, Examples: []string{"1", "0.5"}}, schema[2])
+	assert.Equal(t, labelSchema{Name: "robot", Type: LabelTypeString, Count: 3, Examples: []string{"r1", "r2"}}, schema[3])
+}
+
+func TestLabelWindow(t *testing.T) {
+	now := time.Unix(10_000, 0)
+
+	from, to, err := labelWindow(labelSampleRequest{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-defaultLabelWindow), from)
+	assert.Equal(t, now, to)
+
+	from, to, err = labelWindow(labelSampleRequest{Window: "1h", Stop: 5_000_000}, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.UnixMilli(5_000_000).Add(-time.Hour), from)
+	assert.Equal(t, time.UnixMilli(5_000_000), to)
+
+	from, _, err = labelWindow(labelSampleRequest{Start: 1000, Window: "1h"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.UnixMilli(1000), from)
+
+	_, _, err = labelWindow(labelSampleRequest{Window: "soon"}, now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	model "github.com/reductstore/reduct-go/model"
+)
+
+// latestRecord holds the header and the decoded content of the newest record of an entry.
+type latestRecord struct {
+	entry   string
+	time    int64
+	labels  reductgo.LabelMap
+	content map[string]any
+}
+
+// latestRecords reads the newest record of every entry matching the patterns, sorted by entry name.
+// The timestamp of the record is taken from the entry info, so only a single record is read per entry.
+// Without withBody only the headers are read, otherwise the body is read and decoded right away, so
+// the query of an entry is done before the next one starts. Empty entries are left out.
+func latestRecords(ctx context.Context, bucket reductgo.Bucket, patterns []string, withBody bool) ([]latestRecord, error) {
+	entries, err := bucket.GetEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := filterEntries(entries, patterns)
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+
+	records := make([]latestRecord, 0, len(matched))
+	for _, entry := range matched {
+		if entry.RecordCount == 0 {
+			continue
+		}
+
+		options := reductgo.NewQueryOptionsBuilder().
+			WithWhen(map[string]any{"$limit": 1}).
+			WithStart(entry.LatestRecord).
+			WithStop(entry.LatestRecord + 1).
+			WithHead(!withBody).
+			Build()
+		result, err := bucket.Query(ctx, entry.Name, &options)
+		if err != nil {
+			return nil, err
+		}
+
+		// the record may have been removed since the entries were listed
+		record, ok := <-result.Records()
+		if !ok {
+			continue
+		}
+
+		latest := latestRecord{entry: record.Entry(), time: record.Time(), labels: record.Labels()}
+		if withBody {
+			if b, err := record.Read(); err == nil {
+				latest.content, _ = decodeContent(b)
+			}
+		}
+		records = append(records, latest)
+	}
+	return records, nil
+}
+
+// queryLatest returns a single-row frame per entry with the labels and the content of its newest record.
+func (d *ReductDatasource) queryLatest(ctx context.Context, q plannedQuery) backend.DataResponse {
+	mode := q.query.Options.Mode
+	withLabels := mode == "" || mode == ModeLabelOnly || mode == ModeLabelAndContent
+	withContent := mode == ModeContentOnly || mode == ModeLabelAndContent
+
+	bucket, err := d.reductClient.GetBucket(ctx, q.query.Bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get bucket", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	records, err := latestRecords(ctx, bucket, q.entries, withContent)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to read latest records", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	frames := make([]*data.Frame, 0, len(records))
+	for _, record := range records {
+		frames = append(frames, getLatestFrame(record, withLabels))
+	}
+	return backend.DataResponse{Frames: frames}
+}
+
+// getLatestFrame builds a frame named after the entry with the time of the record and a field per label
+// and flattened JSON path. Label values are typed like the ones of record queries.
+func getLatestFrame(record latestRecord, withLabels bool) *data.Frame {
+	fields := []*data.Field{data.NewField("time", nil, []time.Time{time.UnixMicro(record.time)})}
+
+	if withLabels {
+		labels := make(map[string]any, len(record.labels))
+		for key, value := range record.labels {
+			labels[key] = parseValue(fmt.Sprintf("%v", value))
+		}
+		for _, key := range sortedKeys(labels) {
+			fields = append(fields, newSingleValueField(key, labels[key]))
+		}
+	}
+
+	for _, path := range sortedKeys(record.content) {
+		fields = append(fields, newSingleValueField(path, record.content[path]))
+	}
+
+	frame := data.NewFrame(record.entry, fields...)
+	frame.Meta = &data.FrameMeta{
+		Type: data.FrameTypeTimeSeriesWide,
+	}
+	return frame
+}
+
+// newSingleValueField returns a field holding a single value. Values other than numbers and booleans are
+// formatted as strings.
+func newSingleValueField(name string, value any) *data.Field {
+	switch v := value.(type) {
+	case int64:
+		return data.NewField(name, nil, []int64{v})
+	case float64:
+		return data.NewField(name, nil, []float64{v})
+	case bool:
+		return data.NewField(name, nil, []bool{v})
+	case string:
+		return data.NewField(name, nil, []string{v})
+	default:
+		return data.NewField(name, nil, []string{fmt.Sprintf("%v", v)})
+	}
+}
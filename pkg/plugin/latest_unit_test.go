@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLatestFrame(t *testing.T) {
+	content, _ := decodeContent([]byte(`{"speed": 1.5, "state": {"ok": true}}`))
+	record := latestRecord{
+		entry:   "robot",
+		time:    1_000_000,
+		labels:  reductgo.LabelMap{"mode": "auto", "battery": "87.5", "errors": "2"},
+		content: content,
+	}
+
+	frame := getLatestFrame(record, true)
+
+	assert.Equal(t, "robot", frame.Name)
+	assert.Equal(t, 1, frame.Rows())
+	require.Len(t, frame.Fields, 6)
+
+	names := make([]string, len(frame.Fields))
+	for i, field := range frame.Fields {
+		names[i] = field.Name
+	}
+	assert.Equal(t, []string{"time", "battery", "errors", "mode", "$.speed", "$.state.ok"}, names)
+	assert.Equal(t, time.UnixMicro(1_000_000), frame.Fields[0].At(0))
+	assert.Equal(t, 87.5, frame.Fields[1].At(0))
+	assert.Equal(t, int64(2), frame.Fields[2].At(0))
+	assert.Equal(t, "auto", frame.Fields[3].At(0))
+	assert.Equal(t, 1.5, frame.Fields[4].At(0))
+	assert.Equal(t, true, frame.Fields[5].At(0))
+}
+
+func TestGetLatestFrame_ContentOnly(t *testing.T) {
+	record := latestRecord{
+		entry:   "robot",
+		time:    1,
+		labels:  reductgo.LabelMap{"mode": "auto"},
+		content: map[string]any{"$.speed": 1.5},
+	}
+
+	frame := getLatestFrame(record, false)
+
+	require.Len(t, frame.Fields, 2)
+	assert.Equal(t, "$.speed", frame.Fields[1].Name)
+	assert.Equal(t, data.FrameTypeTimeSeriesWide, frame.Meta.Type)
+}
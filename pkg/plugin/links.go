@@ -0,0 +1,273 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+)
+
+const (
+	// defaultQueryLinkExpiry is the lifetime of a query link when the query doesn't set one.
+	defaultQueryLinkExpiry = 24 * time.Hour
+	// maxQueryLinks limits the links created per query because each one is a request to the server
+	// made before the query returns.
+	maxQueryLinks = 100
+	// maxQueryLinkRequests is the number of links created at the same time.
+	maxQueryLinkRequests = 8
+)
+
+// queryLinkOptions adds a field with a shareable download link of the records behind every point.
+type queryLinkOptions struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Expiry  string `json:"expiry,omitempty"`
+}
+
+// expiry returns the lifetime of the links.
+func (o queryLinkOptions) expiry() (time.Duration, error) {
+	if o.Expiry == "" {
+		return defaultQueryLinkExpiry, nil
+	}
+
+	expiry, err := time.ParseDuration(o.Expiry)
+	if err != nil || expiry <= 0 {
+		return 0, fmt.Errorf("invalid query link expiry '%s'", o.Expiry)
+	}
+	return expiry, nil
+}
+
+// queryLinker creates query links for the points of a query and reuses them for points
+// sharing the same entry, time and group, e.g. the label frames of the same record.
+type queryLinker struct {
+	ctx      context.Context
+	bucket   reductgo.Bucket
+	when     any
+	groupBy  []string
+	expireAt time.Time
+
+	mu     sync.Mutex
+	links  map[string]string
+	failed bool
+}
+
+// linkRequest is a point which needs a link. group holds the group by labels of its series.
+type linkRequest struct {
+	entry string
+	start int64
+	group data.Labels
+}
+
+func (r linkRequest) key() string {
+	return fmt.Sprintf("%s@%d", seriesKey(r.entry, r.group), r.start)
+}
+
+// link returns the link created for the point, if any.
+func (l *queryLinker) link(r linkRequest) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	link, ok := l.links[r.key()]
+	return link, ok
+}
+
+// condition returns the condition of the query narrowed to the records of a group, so the link of a
+// grouped series doesn't download the records of the other groups. A label missing in the group
+// must be missing in the records too.
+func (l *queryLinker) condition(group data.Labels) (any, error) {
+	if len(l.groupBy) == 0 {
+		return l.when, nil
+	}
+
+	condition, err := parseCondition(l.when)
+	if err != nil {
+		return nil, err
+	}
+
+	// the condition is shared by the requests, so it is copied before adding the terms
+	narrowed := make(map[string]any, len(condition)+1)
+	for key, value := range condition {
+		narrowed[key] = value
+	}
+
+	var terms []any
+	if existing, ok := condition["$and"]; ok {
+		list, ok := existing.([]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid condition: expected array for $and")
+		}
+		terms = append(terms, list...)
+	}
+	for _, key := range l.groupBy {
+		if value, ok := group[key]; ok {
+			terms = append(terms, map[string]any{"&" + key: map[string]any{"$eq": value}})
+		} else {
+			terms = append(terms, map[string]any{"&" + key: map[string]any{"$exists": false}})
+		}
+	}
+
+	narrowed["$and"] = terms
+	return narrowed, nil
+}
+
+// create creates the links downloading the records of the points in [start, start+width) with up to
+// maxQueryLinkRequests requests at a time. Points beyond maxQueryLinks links get none.
+func (l *queryLinker) create(requests []linkRequest, width int64) {
+	var pending []linkRequest
+	seen := make(map[string]bool)
+	l.mu.Lock()
+	for _, r := range requests {
+		key := r.key()
+		if _, ok := l.links[key]; ok || seen[key] || len(l.links)+len(pending) >= maxQueryLinks {
+			continue
+		}
+		seen[key] = true
+		pending = append(pending, r)
+	}
+	l.mu.Unlock()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxQueryLinkRequests)
+	for _, r := range pending {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(r linkRequest) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			l.createOne(r, width)
+		}(r)
+	}
+	wg.Wait()
+}
+
+func (l *queryLinker) createOne(r linkRequest, width int64) {
+	l.mu.Lock()
+	failed := l.failed
+	l.mu.Unlock()
+	if failed {
+		return
+	}
+
+	when, err := l.condition(r.group)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to build query link condition", "entry", r.entry, "error", err)
+		l.mu.Lock()
+		l.failed = true
+		l.mu.Unlock()
+		return
+	}
+
+	link, err := l.bucket.CreateQueryLink(l.ctx, r.entry, &reductgo.QueryLinkOptions{
+		Start:    r.start,
+		Stop:     r.start + width,
+		When:     when,
+		ExpireAt: l.expireAt,
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		// the token probably can't create links, so don't try again for every point
+		log.DefaultLogger.Error("Failed to create query link", "entry", r.entry, "time", r.start, "error", err)
+		l.failed = true
+		return
+	}
+	l.links[r.key()] = link
+}
+
+// addQueryLinks appends a "link" field to the time series frames with a download link of the records
+// behind every point. width is the time covered by a point in microseconds: 1 for a record or the window size.
+// Null points have no records and neither have the zero points of stats frames, which are empty windows,
+// so they get no link.
+func addQueryLinks(frames []*data.Frame, linker *queryLinker, width int64, stats bool) {
+	var requests []linkRequest
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 || frame.Fields[0].Type() != data.FieldTypeTime {
+			continue
+		}
+		entry, group := frameEntry(frame.Name), linker.group(frame)
+		for i := 0; i < frame.Fields[0].Len(); i++ {
+			if hasRecords(frame, i, stats) {
+				requests = append(requests, linkRequest{entry, frame.Fields[0].At(i).(time.Time).UnixMicro(), group})
+			}
+		}
+	}
+	linker.create(requests, width)
+
+	complete := true
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 || frame.Fields[0].Type() != data.FieldTypeTime {
+			continue
+		}
+
+		entry, group := frameEntry(frame.Name), linker.group(frame)
+		times := frame.Fields[0]
+		links := make([]string, times.Len())
+		for i := range links {
+			if !hasRecords(frame, i, stats) {
+				continue
+			}
+			link, ok := linker.link(linkRequest{entry, times.At(i).(time.Time).UnixMicro(), group})
+			complete = complete && ok
+			links[i] = link
+		}
+
+		for _, field := range frame.Fields[1:] {
+			if field.Config == nil {
+				field.Config = &data.FieldConfig{}
+			}
+			field.Config.Links = append(field.Config.Links, data.DataLink{
+				Title:       "Download records",
+				URL:         "${__data.fields.link}",
+				TargetBlank: true,
+			})
+		}
+		frame.Fields = append(frame.Fields, data.NewField("link", nil, links))
+	}
+
+	if complete || len(frames) == 0 {
+		return
+	}
+
+	text := fmt.Sprintf("Query links are missing for some points, at most %d links are created per query", maxQueryLinks)
+	if linker.failed {
+		text = "Failed to create query links, check that the token has read access to the bucket"
+	}
+	frames[0].AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: text})
+}
+
+// group returns the group by labels of the series in a frame, which are the same for all its value fields.
+func (l *queryLinker) group(frame *data.Frame) data.Labels {
+	var group data.Labels
+	for _, key := range l.groupBy {
+		if value, ok := frame.Fields[1].Labels[key]; ok {
+			if group == nil {
+				group = data.Labels{}
+			}
+			group[key] = value
+		}
+	}
+	return group
+}
+
+// hasRecords reports whether a row of a frame stands for records: a value field isn't null,
+// nor zero in a stats frame.
+func hasRecords(frame *data.Frame, row int, stats bool) bool {
+	for _, field := range frame.Fields[1:] {
+		if !field.Type().Numeric() {
+			if v, ok := field.ConcreteAt(row); ok && v != nil {
+				return true
+			}
+			continue
+		}
+		v, err := field.NullableFloatAt(row)
+		if err == nil && v != nil && (!stats || *v != 0) {
+			return true
+		}
+	}
+	return false
+}
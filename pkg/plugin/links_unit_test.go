@@ -0,0 +1,127 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryLinkExpiry(t *testing.T) {
+	expiry, err := queryLinkOptions{}.expiry()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultQueryLinkExpiry, expiry)
+
+	expiry, err = queryLinkOptions{Expiry: "15m"}.expiry()
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, expiry)
+
+	_, err = queryLinkOptions{Expiry: "-1h"}.expiry()
+	assert.EqualError(t, err, "invalid query link expiry '-1h'")
+}
+
+func TestLinkWidth(t *testing.T) {
+	width, ok := plannedQuery{}.linkWidth()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), width)
+
+	width, ok = plannedQuery{query: reductQuery{QueryType: QueryTypeStats}, interval: time.Second}.linkWidth()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1_000_000), width)
+
+	_, ok = plannedQuery{query: reductQuery{Options: reductOptions{Mode: ModeLogs}}}.linkWidth()
+	assert.False(t, ok)
+
+	_, ok = plannedQuery{query: reductQuery{QueryType: QueryTypeAnnotations}}.linkWidth()
+	assert.False(t, ok)
+}
+
+func TestAddQueryLinks(t *testing.T) {
+	frame := data.NewFrame("cam/score",
+		data.NewField("time", nil, []time.Time{time.UnixMicro(10), time.UnixMicro(20)}),
+		data.NewField("value", nil, []int64{1, 2}),
+	)
+
+	// links are cached per entry and time, the second point needs a new link which fails
+	linker := &queryLinker{
+		links:  map[string]string{"cam@10": "https://reduct/links/1"},
+		failed: true,
+	}
+	addQueryLinks([]*data.Frame{frame}, linker, 1, false)
+
+	assert.Len(t, frame.Fields, 3)
+	assert.Equal(t, "link", frame.Fields[2].Name)
+	assert.Equal(t, "https://reduct/links/1", frame.Fields[2].At(0))
+	assert.Equal(t, "", frame.Fields[2].At(1))
+	assert.Equal(t, "${__data.fields.link}", frame.Fields[1].Config.Links[0].URL)
+	assert.Len(t, frame.Meta.Notices, 1)
+	assert.Equal(t, data.NoticeSeverityWarning, frame.Meta.Notices[0].Severity)
+}
+
+func TestAddQueryLinks_SkipsEmptyPoints(t *testing.T) {
+	frame := data.NewFrame("cam",
+		data.NewField("time", nil, []time.Time{time.UnixMicro(10), time.UnixMicro(20), time.UnixMicro(30)}),
+		data.NewField("value", nil, floats(3.0, 0.0, nil)),
+	)
+
+	assert.True(t, hasRecords(frame, 1, false))
+	assert.False(t, hasRecords(frame, 1, true))
+	assert.False(t, hasRecords(frame, 2, false))
+
+	// only the first point has records, so no link is missing
+	linker := &queryLinker{links: map[string]string{"cam@10": "https://reduct/links/1"}}
+	addQueryLinks([]*data.Frame{frame}, linker, 10, true)
+
+	assert.Equal(t, "https://reduct/links/1", frame.Fields[2].At(0))
+	assert.Equal(t, "", frame.Fields[2].At(1))
+	assert.Equal(t, "", frame.Fields[2].At(2))
+	assert.Nil(t, frame.Meta)
+}
+
+func TestQueryLinkerCondition(t *testing.T) {
+	when := map[string]any{"$and": []any{map[string]any{"&mode": map[string]any{"$eq": "auto"}}}}
+
+	linker := &queryLinker{when: when}
+	condition, err := linker.condition(data.Labels{"robot_id": "r1"})
+	assert.NoError(t, err)
+	assert.Equal(t, when, condition)
+
+	// the link of a series matches only the records of its group, the condition of the query is kept as is
+	linker = &queryLinker{when: when, groupBy: []string{"robot_id", "site"}}
+	condition, err = linker.condition(data.Labels{"robot_id": "r1"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"$and": []any{
+		map[string]any{"&mode": map[string]any{"$eq": "auto"}},
+		map[string]any{"&robot_id": map[string]any{"$eq": "r1"}},
+		map[string]any{"&site": map[string]any{"$exists": false}},
+	}}, condition)
+	assert.Len(t, when["$and"], 1)
+
+	linker = &queryLinker{when: `{"$and": 1}`, groupBy: []string{"robot_id"}}
+	_, err = linker.condition(nil)
+	assert.EqualError(t, err, "invalid condition: expected array for $and")
+}
+
+func TestAddQueryLinks_Groups(t *testing.T) {
+	newFrame := func(robot string) *data.Frame {
+		return data.NewFrame("robots/battery{robot_id="+robot+"}",
+			data.NewField("time", nil, []time.Time{time.UnixMicro(10)}),
+			data.NewField("value", data.Labels{"robot_id": robot}, []int64{1}),
+		)
+	}
+	frames := []*data.Frame{newFrame("r1"), newFrame("r2")}
+
+	// the series share the time but not the records, so each group has its own link
+	linker := &queryLinker{
+		groupBy: []string{"robot_id"},
+		links: map[string]string{
+			"robots{robot_id=r1}@10": "https://reduct/links/1",
+			"robots{robot_id=r2}@10": "https://reduct/links/2",
+		},
+	}
+	addQueryLinks(frames, linker, 1, false)
+
+	assert.Equal(t, "https://reduct/links/1", frames[0].Fields[2].At(0))
+	assert.Equal(t, "https://reduct/links/2", frames[1].Fields[2].At(0))
+}
@@ -0,0 +1,340 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// Severities of lint issues.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// lintIssue is a problem found in a condition. Pointer is a JSON pointer (RFC 6901) into the condition.
+type lintIssue struct {
+	Pointer  string `json:"pointer"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+type operatorKind int
+
+const (
+	operatorLogical operatorKind = iota
+	operatorEquality
+	operatorOrdering
+	operatorMembership
+	operatorString
+	operatorArithmetic
+	operatorMisc
+)
+
+// operatorSpec is the number of operands an operator accepts; a negative maximum means unbounded.
+type operatorSpec struct {
+	min  int
+	max  int
+	kind operatorKind
+}
+
+var conditionOperators = map[string]operatorSpec{
+	"$and":         {1, -1, operatorLogical},
+	"$all_of":      {1, -1, operatorLogical},
+	"$or":          {1, -1, operatorLogical},
+	"$any_of":      {1, -1, operatorLogical},
+	"$xor":         {1, -1, operatorLogical},
+	"$one_of":      {1, -1, operatorLogical},
+	"$none_of":     {1, -1, operatorLogical},
+	"$not":         {1, 1, operatorLogical},
+	"$eq":          {2, 2, operatorEquality},
+	"$ne":          {2, 2, operatorEquality},
+	"$gt":          {2, 2, operatorOrdering},
+	"$gte":         {2, 2, operatorOrdering},
+	"$lt":          {2, 2, operatorOrdering},
+	"$lte":         {2, 2, operatorOrdering},
+	"$in":          {2, -1, operatorMembership},
+	"$nin":         {2, -1, operatorMembership},
+	"$contains":    {2, 2, operatorString},
+	"$starts_with": {2, 2, operatorString},
+	"$ends_with":   {2, 2, operatorString},
+	"$regex":       {2, 2, operatorString},
+	"$add":         {2, -1, operatorArithmetic},
+	"$sub":         {2, 2, operatorArithmetic},
+	"$mult":        {2, -1, operatorArithmetic},
+	"$div":         {2, 2, operatorArithmetic},
+	"$div_num":     {2, 2, operatorArithmetic},
+	"$rem":         {2, 2, operatorArithmetic},
+	"$abs":         {1, 1, operatorArithmetic},
+	"$exists":      {1, 2, operatorMisc},
+	"$has":         {1, 2, operatorMisc},
+	"$cast":        {2, 2, operatorMisc},
+	"$ref":         {1, 1, operatorMisc},
+}
+
+// conditionDirectives are top level keys which control the query instead of filtering records.
+var conditionDirectives = map[string]bool{
+	"$limit":  true,
+	"$each_n": true,
+	"$each_t": true,
+}
+
+// conditionLinter walks a condition and collects issues. labels maps label names to their sampled
+// type; a nil map disables the checks needing a schema.
+type conditionLinter struct {
+	labels map[string]string
+	issues []lintIssue
+}
+
+// lintCondition checks the condition offline against the sampled label schema.
+func lintCondition(condition map[string]any, schema []labelSchema) []lintIssue {
+	linter := &conditionLinter{}
+	if schema != nil {
+		linter.labels = make(map[string]string, len(schema))
+		for _, label := range schema {
+			linter.labels[label.Name] = label.Type
+		}
+	}
+
+	linter.object(condition, "", true)
+	if linter.issues == nil {
+		return []lintIssue{}
+	}
+	return linter.issues
+}
+
+func (l *conditionLinter) report(pointer, severity, format string, args ...any) {
+	l.issues = append(l.issues, lintIssue{
+		Pointer:  pointer,
+		Message:  fmt.Sprintf(format, args...),
+		Severity: severity,
+	})
+}
+
+// object lints an expression object. Directives are only accepted at the top level.
+func (l *conditionLinter) object(obj map[string]any, pointer string, topLevel bool) {
+	for _, key := range sortedKeys(obj) {
+		value := obj[key]
+		keyPointer := pointer + "/" + escapePointer(key)
+
+		switch {
+		case strings.HasPrefix(key, "#"):
+			// extension directives are checked by the server
+		case conditionDirectives[key]:
+			if !topLevel {
+				l.report(keyPointer, LintError, "directive '%s' is only allowed at the top level", key)
+			} else if _, ok := value.(map[string]any); ok {
+				l.report(keyPointer, LintError, "directive '%s' expects a scalar value", key)
+			}
+		case strings.HasPrefix(key, "$"):
+			l.operator(key, value, keyPointer, nil)
+		case strings.HasPrefix(key, "&") || strings.HasPrefix(key, "@"):
+			l.reference(key, keyPointer)
+			ops, ok := value.(map[string]any)
+			if !ok {
+				l.report(keyPointer, LintError, "expected an object with operators for '%s'", key)
+				continue
+			}
+			for _, op := range sortedKeys(ops) {
+				ref := key
+				l.operator(op, ops[op], keyPointer+"/"+escapePointer(op), &ref)
+			}
+		default:
+			l.report(keyPointer, LintError, "unknown operand '%s': labels are referenced with '&'", key)
+		}
+	}
+}
+
+// operator lints an operator with its operands. implicit is the left operand of the field form
+// {"&label": {"$op": value}}.
+func (l *conditionLinter) operator(op string, value any, pointer string, implicit *string) {
+	spec, ok := conditionOperators[op]
+	if !ok {
+		l.report(pointer, LintError, "unknown operator '%s'", op)
+		return
+	}
+
+	var operands []any
+	var pointers []string
+	if list, ok := value.([]any); ok {
+		for i, operand := range list {
+			operands = append(operands, operand)
+			pointers = append(pointers, pointer+"/"+strconv.Itoa(i))
+		}
+	} else {
+		operands = []any{value}
+		pointers = []string{pointer}
+	}
+
+	// a list given to a membership operator in the field form is the set of values
+	if implicit != nil && spec.kind == operatorMembership {
+		operands = []any{value}
+		pointers = []string{pointer}
+	}
+
+	all := operands
+	if implicit != nil {
+		all = append([]any{*implicit}, operands...)
+	}
+
+	count := len(all)
+	if count < spec.min || (spec.max >= 0 && count > spec.max) {
+		l.report(pointer, LintError, "operator '%s' expects %s, got %d", op, arityText(spec), count)
+	}
+
+	for i, operand := range operands {
+		l.operand(operand, pointers[i])
+	}
+
+	if spec.kind == operatorEquality || spec.kind == operatorOrdering || spec.kind == operatorString {
+		l.types(op, spec.kind, all, pointer)
+	}
+}
+
+// operand lints a nested expression or a label reference.
+func (l *conditionLinter) operand(operand any, pointer string) {
+	switch v := operand.(type) {
+	case map[string]any:
+		l.object(v, pointer, false)
+	case []any:
+		for i, item := range v {
+			l.operand(item, pointer+"/"+strconv.Itoa(i))
+		}
+	case string:
+		if strings.HasPrefix(v, "&") {
+			l.reference(v, pointer)
+		}
+	}
+}
+
+// reference checks that a referenced label was seen in the sampled records.
+func (l *conditionLinter) reference(ref string, pointer string) {
+	if l.labels == nil || !strings.HasPrefix(ref, "&") {
+		return
+	}
+
+	name := strings.TrimPrefix(ref, "&")
+	if _, ok := l.labels[name]; !ok {
+		l.report(pointer, LintWarning, "label '%s' was not found in the sampled records", name)
+	}
+}
+
+// types flags comparisons between a label and a literal of an incompatible type.
+func (l *conditionLinter) types(op string, kind operatorKind, operands []any, pointer string) {
+	if l.labels == nil || len(operands) != 2 {
+		return
+	}
+
+	for i, operand := range operands {
+		ref, ok := operand.(string)
+		if !ok || !strings.HasPrefix(ref, "&") {
+			continue
+		}
+		labelType, ok := l.labels[strings.TrimPrefix(ref, "&")]
+		if !ok {
+			continue
+		}
+
+		other := operands[1-i]
+		if s, ok := other.(string); ok && (strings.HasPrefix(s, "&") || strings.HasPrefix(s, "@") || strings.HasPrefix(s, "$")) {
+			continue
+		}
+		if _, ok := other.(map[string]any); ok {
+			continue
+		}
+
+		numeric := labelType == LabelTypeInt || labelType == LabelTypeFloat
+		switch kind {
+		case operatorOrdering:
+			if !numeric {
+				l.report(pointer, LintWarning, "operator '%s' compares %s label '%s' which is not numeric", op, labelType, ref[1:])
+			} else if !isNumericLiteral(other) {
+				l.report(pointer, LintWarning, "operator '%s' compares numeric label '%s' with a non-numeric value", op, ref[1:])
+			}
+		case operatorEquality:
+			if numeric && !isNumericLiteral(other) {
+				l.report(pointer, LintWarning, "operator '%s' compares numeric label '%s' with a non-numeric value", op, ref[1:])
+			}
+		case operatorString:
+			if labelType != LabelTypeString {
+				l.report(pointer, LintWarning, "operator '%s' expects a string label but '%s' is %s", op, ref[1:], labelType)
+			}
+		}
+	}
+}
+
+func isNumericLiteral(v any) bool {
+	switch t := v.(type) {
+	case float64, int, int64:
+		return true
+	case string:
+		_, err := strconv.ParseFloat(t, 64)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+func arityText(spec operatorSpec) string {
+	switch {
+	case spec.min == spec.max && spec.min == 1:
+		return "1 operand"
+	case spec.min == spec.max:
+		return fmt.Sprintf("%d operands", spec.min)
+	case spec.max < 0:
+		return fmt.Sprintf("at least %d operands", spec.min)
+	default:
+		return fmt.Sprintf("%d to %d operands", spec.min, spec.max)
+	}
+}
+
+// escapePointer escapes a key for a JSON pointer.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func (d *ReductDatasource) handleLintCondition(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var payload struct {
+		labelSampleRequest
+		Condition any `json:"condition"`
+	}
+
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		log.DefaultLogger.Warn("Invalid lint request", "error", err)
+		return sendError(sender, http.StatusBadRequest, "invalid request body")
+	}
+
+	condition, err := parseCondition(payload.Condition)
+	if err != nil {
+		return sendJSON(sender, http.StatusOK, map[string]any{
+			"issues": []lintIssue{{Pointer: "", Message: err.Error(), Severity: LintError}},
+		})
+	}
+
+	// without a bucket there is no schema and only the syntax is checked
+	var schema []labelSchema
+	if payload.Bucket != "" {
+		from, to, err := labelWindow(payload.labelSampleRequest, time.Now())
+		if err != nil {
+			return sendError(sender, http.StatusBadRequest, err.Error())
+		}
+
+		records, err := d.sampleRecords(ctx, payload.Bucket, payload.Entries, nil, from, to, labelKeySampleSize)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to sample labels", "bucket", payload.Bucket, "error", err)
+			return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error sampling labels: %v", err))
+		}
+		schema = inferLabelSchema(records)
+	}
+
+	return sendJSON(sender, http.StatusOK, map[string]any{
+		"issues": lintCondition(condition, schema),
+	})
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintConditionWithoutSchema(t *testing.T) {
+	condition := map[string]any{
+		"$limit": 10,
+		"&speed": map[string]any{"$gt": 10, "$between": 1},
+		"$and": []any{
+			map[string]any{"$eq": []any{"&mode"}},
+			map[string]any{"mode": map[string]any{"$eq": "auto"}},
+		},
+		"#ext": map[string]any{"anything": true},
+	}
+
+	issues := lintCondition(condition, nil)
+	assert.Equal(t, []lintIssue{
+		{Pointer: "/$and/0/$eq", Message: "operator '$eq' expects 2 operands, got 1", Severity: LintError},
+		{Pointer: "/$and/1/mode", Message: "unknown operand 'mode': labels are referenced with '&'", Severity: LintError},
+		{Pointer: "/&speed/$between", Message: "unknown operator '$between'", Severity: LintError},
+	}, issues)
+}
+
+func TestLintConditionWithSchema(t *testing.T) {
+	schema := []labelSchema{
+		{Name: "speed", Type: LabelTypeFloat},
+		{Name: "mode", Type: LabelTypeString},
+	}
+	condition := map[string]any{
+		"$or": []any{
+			map[string]any{"&mode": map[string]any{"$gt": 1}},
+			map[string]any{"$eq": []any{"&speed", "fast"}},
+			map[string]any{"&battery": map[string]any{"$lt": 20}},
+			map[string]any{"&mode": map[string]any{"$in": []any{"auto", "manual"}}},
+		},
+	}
+
+	issues := lintCondition(condition, schema)
+	assert.Equal(t, []lintIssue{
+		{Pointer: "/$or/0/&mode/$gt", Message: "operator '$gt' compares string label 'mode' which is not numeric", Severity: LintWarning},
+		{Pointer: "/$or/1/$eq", Message: "operator '$eq' compares numeric label 'speed' with a non-numeric value", Severity: LintWarning},
+		{Pointer: "/$or/2/&battery", Message: "label 'battery' was not found in the sampled records", Severity: LintWarning},
+	}, issues)
+}
+
+func TestLintConditionDirectives(t *testing.T) {
+	issues := lintCondition(map[string]any{
+		"$each_t": map[string]any{"x": 1},
+		"$not":    []any{map[string]any{"$limit": 1}},
+	}, nil)
+	assert.Equal(t, []lintIssue{
+		{Pointer: "/$each_t", Message: "directive '$each_t' expects a scalar value", Severity: LintError},
+		{Pointer: "/$not/0/$limit", Message: "directive '$limit' is only allowed at the top level", Severity: LintError},
+	}, issues)
+
+	assert.Equal(t, []lintIssue{}, lintCondition(map[string]any{}, nil))
+}
+
+func TestEscapePointer(t *testing.T) {
+	assert.Equal(t, "a~1b~0c", escapePointer("a/b~c"))
+}
@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+)
+
+// getLogFrames turns text records into a single log lines frame. Every non-empty line of a record
+// becomes a log line with the record timestamp. The severity is read from the label named by
+// severity or, if it starts with "$", from the flattened JSON path of the line.
+func getLogFrames(records <-chan *reductgo.ReadableRecord, severity string) []*data.Frame {
+	var (
+		timestamps []time.Time
+		bodies     []string
+		severities []string
+		labels     []json.RawMessage
+	)
+
+	for record := range records {
+		s, err := record.ReadAsString()
+		if err != nil {
+			log.DefaultLogger.Error("Failed to read record", "entry", record.Entry(), "time", record.Time(), "error", err)
+			continue
+		}
+
+		recordLabels := logLabels(record)
+		encodedLabels, err := json.Marshal(recordLabels)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to encode labels", "entry", record.Entry(), "error", err)
+			continue
+		}
+
+		for _, line := range strings.Split(s, "\n") {
+			line = strings.TrimRight(line, "\r")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			timestamps = append(timestamps, time.UnixMicro(record.Time()))
+			bodies = append(bodies, line)
+			severities = append(severities, logSeverity(line, recordLabels, severity))
+			labels = append(labels, encodedLabels)
+		}
+	}
+
+	frame := data.NewFrame("logs",
+		data.NewField("timestamp", nil, timestamps),
+		data.NewField("body", nil, bodies),
+		data.NewField("severity", nil, severities),
+		data.NewField("labels", nil, labels),
+	)
+	frame.Meta = &data.FrameMeta{
+		Type:                   data.FrameTypeLogLines,
+		PreferredVisualization: data.VisTypeLogs,
+	}
+	return []*data.Frame{frame}
+}
+
+// logLabels returns the record labels as strings together with the entry name.
+func logLabels(record *reductgo.ReadableRecord) map[string]string {
+	result := make(map[string]string, len(record.Labels())+1)
+	for key, value := range record.Labels() {
+		result[key] = fmt.Sprintf("%v", value)
+	}
+	result["entry"] = record.Entry()
+	return result
+}
+
+// logSeverity resolves the severity of a log line from a label or a JSON path.
+func logSeverity(line string, labels map[string]string, severity string) string {
+	if severity == "" {
+		return ""
+	}
+
+	if !strings.HasPrefix(severity, "$") {
+		return labels[severity]
+	}
+
+	flat, ok := decodeContent([]byte(line))
+	if !ok {
+		return ""
+	}
+	if value, ok := flat[severity]; ok && value != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return ""
+}
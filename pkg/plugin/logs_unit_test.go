@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLogFrames(t *testing.T) {
+	records := make(chan *reductgo.ReadableRecord, 2)
+	records <- reductgo.NewReadableRecord("app", 1000, 0, false,
+		io.NopCloser(strings.NewReader("first line\nsecond line\n")), reductgo.LabelMap{"level": "warn"}, "text/plain")
+	records <- reductgo.NewReadableRecord("app", 2000, 0, true,
+		io.NopCloser(strings.NewReader(`{"level":"error","msg":"boom"}`)), reductgo.LabelMap{}, "application/json")
+	close(records)
+
+	frames := getLogFrames(records, "level")
+	require.Len(t, frames, 1)
+
+	frame := frames[0]
+	assert.Equal(t, data.FrameTypeLogLines, frame.Meta.Type)
+	assert.Equal(t, data.VisType(data.VisTypeLogs), frame.Meta.PreferredVisualization)
+	require.Equal(t, 3, frame.Rows())
+
+	assert.Equal(t, "first line", frame.Fields[1].At(0))
+	assert.Equal(t, "second line", frame.Fields[1].At(1))
+	assert.Equal(t, "warn", frame.Fields[2].At(0))
+	assert.Equal(t, "", frame.Fields[2].At(2))
+
+	var labels map[string]string
+	require.NoError(t, json.Unmarshal(frame.Fields[3].At(0).(json.RawMessage), &labels))
+	assert.Equal(t, map[string]string{"level": "warn", "entry": "app"}, labels)
+}
+
+func TestLogSeverity(t *testing.T) {
+	labels := map[string]string{"level": "info"}
+
+	assert.Equal(t, "info", logSeverity("text", labels, "level"))
+	assert.Equal(t, "", logSeverity("text", labels, ""))
+	assert.Equal(t, "error", logSeverity(`{"log":{"level":"error"}}`, labels, "$.log.level"))
+	assert.Equal(t, "", logSeverity("not json", labels, "$.level"))
+}
@@ -0,0 +1,357 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	model "github.com/reductstore/reduct-go/model"
+)
+
+// plannedQuery is a parsed and validated data query waiting to be executed.
+type plannedQuery struct {
+	refID    string
+	query    reductQuery
+	entries  []string
+	from     time.Time
+	to       time.Time
+	interval time.Duration
+	// shift is the time shift the range was moved into the past by.
+	shift time.Duration
+	// datasourceUID is used to link frames back to the record resource.
+	datasourceUID string
+	// expressions are compiled from the query options.
+	expressions []*expression
+}
+
+// needsContent reports whether the query requires record bodies to be downloaded.
+func (q plannedQuery) needsContent() bool {
+	if q.query.QueryType != QueryTypeRecords {
+		return false
+	}
+	return needsContent(q.query.Options.Mode) || expressionsUseContent(q.expressions)
+}
+
+// buildFrames turns the records of a scan into the frames of the query.
+func (q plannedQuery) buildFrames(records <-chan *reductgo.ReadableRecord) ([]*data.Frame, error) {
+	switch q.query.QueryType {
+	case QueryTypeStats:
+		return getStatsFrames(records, q.from, q.to, q.interval), nil
+	case QueryTypeAnnotations:
+		return getAnnotationFrames(records, q.query.Options.Annotations, q.to), nil
+	default:
+		if q.query.Options.Mode == ModeLogs {
+			return getLogFrames(records, q.query.Options.Severity), nil
+		}
+		frames, err := getFrames(records, q.query.Options.Mode, q.query.Options.GroupBy, q.expressions)
+		if err != nil {
+			return nil, err
+		}
+		return q.processFrames(frames), nil
+	}
+}
+
+// errBuildFrames is returned for a query whose frames couldn't be built because of a panic.
+var errBuildFrames = errors.New("failed to build frames")
+
+// safeBuildFrames builds the frames in a scan goroutine, where a panic would take down the plugin.
+// It recovers from a panic and drains the records so that the other queries of the scan don't block.
+func (q plannedQuery) safeBuildFrames(records <-chan *reductgo.ReadableRecord) (frames []*data.Frame, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.DefaultLogger.Error("Failed to build frames", "ref_id", q.refID, "panic", r, "stack", string(debug.Stack()))
+			frames, err = nil, fmt.Errorf("%w: %v", errBuildFrames, r)
+			for range records {
+			}
+		}
+	}()
+	return q.buildFrames(records)
+}
+
+// processFrames runs the post-processing of the record frames in order: aggregations, transforms,
+// alignment, gap filling and record links. Windows and grids use the query interval.
+// The options were validated with the query, so their errors are ignored here.
+func (q plannedQuery) processFrames(frames []*data.Frame) []*data.Frame {
+	options := q.query.Options
+	step := statsStep(q.from, q.to, q.interval)
+	interval := time.Duration(step) * time.Microsecond
+
+	if len(options.Aggregations) > 0 {
+		aggs, _ := parseAggregations(options.Aggregations)
+		frames = aggregateFrames(frames, aggs, step)
+	}
+	if len(options.Transforms) > 0 {
+		transformFrames(frames, options.Transforms)
+	}
+	if options.Align.Enabled {
+		tolerance, _ := options.Align.tolerance(interval)
+		frames = alignFrames(frames, options.Align.Series, options.Align.Match, q.from, q.to, interval, tolerance)
+	}
+	if options.GapFill.Mode != "" {
+		staleness, _ := options.GapFill.staleness(interval)
+		fillGaps(frames, options.GapFill.Mode, staleness, interval)
+	}
+	// an aligned frame mixes entries, an aggregated point is a window and a filled gap has no records,
+	// so their points can't link to a record
+	filled := options.GapFill.Mode != "" && options.GapFill.Mode != GapFillNull
+	if options.RecordLinks && q.datasourceUID != "" && !options.Align.Enabled && len(options.Aggregations) == 0 && !filled {
+		addRecordLinks(frames, q.datasourceUID, q.query.Bucket, q.shift)
+	}
+	return frames
+}
+
+// linkWidth returns the time in microseconds covered by a point of the query frames, or false if
+// the frames of the query can't carry query links.
+func (q plannedQuery) linkWidth() (int64, bool) {
+	switch {
+	case q.query.Options.Align.Enabled:
+		return 0, false
+	case q.query.QueryType == QueryTypeStats,
+		q.query.QueryType == QueryTypeRecords && len(q.query.Options.Aggregations) > 0:
+		return statsStep(q.from, q.to, q.interval), true
+	case q.query.QueryType == QueryTypeRecords && q.query.Options.Mode != ModeLogs:
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// scanGroup is a set of queries that can be served by a single QueryMany call.
+type scanGroup struct {
+	bucket  string
+	entries []string
+	from    time.Time
+	to      time.Time
+	options reductOptions
+	members []plannedQuery
+}
+
+// scanPlanner groups compatible queries so that panels issuing several queries
+// against the same bucket, entries and time range run only one scan.
+type scanPlanner struct {
+	order  []string
+	byKeys map[string]*scanGroup
+}
+
+func newScanPlanner() *scanPlanner {
+	return &scanPlanner{byKeys: make(map[string]*scanGroup)}
+}
+
+// add assigns the query to an existing compatible group or starts a new one.
+func (p *scanPlanner) add(q plannedQuery) {
+	key := scanKey(q)
+	group, ok := p.byKeys[key]
+	if !ok {
+		group = &scanGroup{
+			bucket:  q.query.Bucket,
+			entries: q.entries,
+			from:    q.from,
+			to:      q.to,
+			options: q.query.Options,
+		}
+		p.byKeys[key] = group
+		p.order = append(p.order, key)
+	}
+	group.members = append(group.members, q)
+}
+
+// groups returns the planned scans in the order their first query was added.
+func (p *scanPlanner) groups() []*scanGroup {
+	result := make([]*scanGroup, 0, len(p.order))
+	for _, key := range p.order {
+		result = append(result, p.byKeys[key])
+	}
+	return result
+}
+
+// scanKey identifies the parameters which change the set of records returned by the server.
+// The mode and query type are deliberately left out because they only decide which parts of a record are used.
+func scanKey(q plannedQuery) string {
+	entries := append([]string(nil), q.entries...)
+	sort.Strings(entries)
+
+	when, _ := json.Marshal(q.query.Options.When)
+
+	key, _ := json.Marshal([]any{
+		q.query.Bucket,
+		strings.Join(entries, "\x00"),
+		q.from.UnixMicro(),
+		q.to.UnixMicro(),
+		string(when),
+	})
+	return string(key)
+}
+
+// needsContent reports whether the mode requires record bodies to be downloaded.
+func needsContent(mode ReductMode) bool {
+	return mode == ModeContentOnly || mode == ModeLabelAndContent || mode == ModeLogs
+}
+
+// withContent reports whether any member of the group needs record bodies.
+func (g *scanGroup) withContent() bool {
+	for _, m := range g.members {
+		if m.needsContent() {
+			return true
+		}
+	}
+	return false
+}
+
+// queryOptions builds the scan options using the widest mode needed by the group.
+func (g *scanGroup) queryOptions() reductgo.QueryOptions {
+	options := reductgo.NewQueryOptionsBuilder().
+		WithWhen(g.options.When).
+		WithHead(!g.withContent())
+
+	if !g.from.IsZero() {
+		options.WithStart(g.from.UnixMicro())
+	}
+	if !g.to.IsZero() {
+		options.WithStop(g.to.UnixMicro())
+	}
+	return options.Build()
+}
+
+// runScan executes one QueryMany for the group and builds the frames of every member.
+func (d *ReductDatasource) runScan(ctx context.Context, group *scanGroup) map[string]backend.DataResponse {
+	responses := make(map[string]backend.DataResponse, len(group.members))
+	failAll := func(res backend.DataResponse) map[string]backend.DataResponse {
+		for _, m := range group.members {
+			responses[m.refID] = res
+		}
+		return responses
+	}
+
+	log.DefaultLogger.Debug(
+		"Running shared scan",
+		"bucket", group.bucket,
+		"entries", group.entries,
+		"queries", len(group.members),
+		"content", group.withContent(),
+	)
+
+	bucket, err := d.reductClient.GetBucket(ctx, group.bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get bucket", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return failAll(backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message))
+	}
+
+	options := group.queryOptions()
+	records, err := bucket.QueryMany(ctx, group.entries, &options)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to query", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return failAll(backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message))
+	}
+
+	sinks := make([]chan *reductgo.ReadableRecord, len(group.members))
+	frames := make([][]*data.Frame, len(group.members))
+	failures := make([]error, len(group.members))
+
+	var wg sync.WaitGroup
+	for i, m := range group.members {
+		sinks[i] = make(chan *reductgo.ReadableRecord, 64)
+		wg.Add(1)
+		go func(i int, m plannedQuery) {
+			defer wg.Done()
+			frames[i], failures[i] = m.safeBuildFrames(sinks[i])
+		}(i, m)
+	}
+
+	fanOutRecords(records.Records(), sinks, group.withContent())
+	wg.Wait()
+
+	for i, m := range group.members {
+		width, ok := m.linkWidth()
+		if !m.query.Options.QueryLinks.Enabled || !ok {
+			continue
+		}
+		// the expiry was validated with the query
+		expiry, _ := m.query.Options.QueryLinks.expiry()
+		addQueryLinks(frames[i], &queryLinker{
+			ctx:      ctx,
+			bucket:   bucket,
+			when:     group.options.When,
+			groupBy:  m.query.Options.GroupBy,
+			expireAt: time.Now().Add(expiry),
+			links:    make(map[string]string),
+		}, width, m.query.QueryType == QueryTypeStats)
+	}
+
+	// the query links are created with the times of the records, so the frames are shifted last
+	for i, m := range group.members {
+		if m.shift > 0 {
+			shiftFrames(frames[i], m.shift, m.query.Options.TimeShift)
+		}
+	}
+
+	for i, m := range group.members {
+		if failures[i] != nil {
+			status := backend.StatusBadRequest
+			if errors.Is(failures[i], errBuildFrames) {
+				status = backend.StatusInternal
+			}
+			responses[m.refID] = backend.ErrDataResponse(status, failures[i].Error())
+			continue
+		}
+		responses[m.refID] = backend.DataResponse{
+			Frames: frames[i],
+		}
+	}
+	return responses
+}
+
+// fanOutRecords copies every record into each sink and closes the sinks when the source is drained.
+// With more than one sink the body is read once and every sink gets its own reader over it.
+func fanOutRecords(source <-chan *reductgo.ReadableRecord, sinks []chan *reductgo.ReadableRecord, withContent bool) {
+	defer func() {
+		for _, sink := range sinks {
+			close(sink)
+		}
+	}()
+
+	if len(sinks) == 1 {
+		for record := range source {
+			sinks[0] <- record
+		}
+		return
+	}
+
+	for record := range source {
+		var body []byte
+		if withContent {
+			b, err := record.Read()
+			if err != nil {
+				log.DefaultLogger.Error("Failed to read record", "entry", record.Entry(), "time", record.Time(), "error", err)
+			}
+			body = b
+		}
+
+		for _, sink := range sinks {
+			sink <- reductgo.NewReadableRecord(
+				record.Entry(),
+				record.Time(),
+				record.Size(),
+				record.IsLast(),
+				io.NopCloser(bytes.NewReader(body)),
+				record.Labels(),
+				record.ContentType(),
+			)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPlannedQuery(refID string, entries []string, mode ReductMode, when any) plannedQuery {
+	return plannedQuery{
+		refID: refID,
+		query: reductQuery{
+			Bucket:  "bucket",
+			Entries: entries,
+			Options: reductOptions{Mode: mode, When: when},
+		},
+		entries: entries,
+		from:    time.UnixMicro(1000),
+		to:      time.UnixMicro(2000),
+	}
+}
+
+func TestScanPlanner(t *testing.T) {
+	t.Run("groups queries that differ only in mode", func(t *testing.T) {
+		planner := newScanPlanner()
+		planner.add(newPlannedQuery("A", []string{"a", "b"}, ModeLabelOnly, nil))
+		planner.add(newPlannedQuery("B", []string{"b", "a"}, ModeContentOnly, nil))
+
+		groups := planner.groups()
+		require.Len(t, groups, 1)
+		assert.Len(t, groups[0].members, 2)
+		assert.True(t, groups[0].withContent())
+	})
+
+	t.Run("keeps queries with different conditions apart", func(t *testing.T) {
+		planner := newScanPlanner()
+		planner.add(newPlannedQuery("A", []string{"a"}, ModeLabelOnly, map[string]any{"&x": map[string]any{"$gt": 1}}))
+		planner.add(newPlannedQuery("B", []string{"a"}, ModeLabelOnly, nil))
+
+		groups := planner.groups()
+		require.Len(t, groups, 2)
+		assert.Equal(t, "A", groups[0].members[0].refID)
+		assert.Equal(t, "B", groups[1].members[0].refID)
+		assert.False(t, groups[0].withContent())
+	})
+}
+
+func TestFanOutRecords(t *testing.T) {
+	source := make(chan *reductgo.ReadableRecord, 1)
+	source <- reductgo.NewReadableRecord("entry", 1000, 10, true, io.NopCloser(strings.NewReader(`{"x":1}`)), reductgo.LabelMap{"a": "1"}, "application/json")
+	close(source)
+
+	sinks := []chan *reductgo.ReadableRecord{
+		make(chan *reductgo.ReadableRecord, 1),
+		make(chan *reductgo.ReadableRecord, 1),
+	}
+	fanOutRecords(source, sinks, true)
+
+	for _, sink := range sinks {
+		record, ok := <-sink
+		require.True(t, ok)
+		body, err := record.ReadAsString()
+		require.NoError(t, err)
+		assert.Equal(t, `{"x":1}`, body)
+		assert.Equal(t, "entry", record.Entry())
+		assert.Equal(t, "1", record.Labels()["a"])
+
+		_, ok = <-sink
+		assert.False(t, ok)
+	}
+}
+
+func TestSafeBuildFrames_RecoversFromPanic(t *testing.T) {
+	q := newPlannedQuery("A", []string{"e"}, ModeContentOnly, nil)
+
+	// a nil record can't come from a scan, it stands in for any bug while building the frames
+	records := make(chan *reductgo.ReadableRecord, 3)
+	records <- reductgo.NewReadableRecord("e", 1, 0, false, io.NopCloser(strings.NewReader(`{"value": 1}`)), nil, "")
+	records <- nil
+	records <- reductgo.NewReadableRecord("e", 2, 0, false, io.NopCloser(strings.NewReader(`{"value": 2}`)), nil, "")
+	close(records)
+
+	frames, err := q.safeBuildFrames(records)
+	assert.Nil(t, frames)
+	require.ErrorIs(t, err, errBuildFrames)
+	assert.Empty(t, records)
+}
+
+func TestSafeBuildFrames_ContentTypeChange(t *testing.T) {
+	q := newPlannedQuery("A", []string{"e"}, ModeContentOnly, nil)
+
+	records := make(chan *reductgo.ReadableRecord, 3)
+	for i, body := range []string{`{"value": 1}`, `{"value": "high"}`, `{"value": "2"}`} {
+		records <- reductgo.NewReadableRecord("e", int64(i), 0, false, io.NopCloser(strings.NewReader(body)), nil, "")
+	}
+	close(records)
+
+	// the string which isn't a number is skipped, the other one is coerced to the type of the series
+	frames, err := q.safeBuildFrames(records)
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	assert.Equal(t, "e/$.value", frames[0].Name)
+	assert.Equal(t, data.NewField("value", nil, []float64{1, 2}), frames[0].Fields[1])
+}
+
+func TestProcessFrames_RecordLinks(t *testing.T) {
+	newFrames := func() []*data.Frame {
+		return []*data.Frame{data.NewFrame("e/value",
+			data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(1, 0)}),
+			data.NewField("value", nil, []float64{1, 2}),
+		)}
+	}
+
+	q := newPlannedQuery("A", []string{"e"}, ModeLabelOnly, nil)
+	q.datasourceUID = "ds-uid"
+	q.from, q.to, q.interval = time.Unix(0, 0), time.Unix(10, 0), time.Second
+	q.query.Options.RecordLinks = true
+
+	frames := q.processFrames(newFrames())
+	require.NotNil(t, frames[0].Fields[1].Config)
+	assert.Len(t, frames[0].Fields[1].Config.Links, 1)
+
+	// the points of an aggregation are windows without a record of their own
+	q.query.Options.Aggregations = []string{"mean"}
+	frames = q.processFrames(newFrames())
+	for _, field := range frames[0].Fields {
+		assert.True(t, field.Config == nil || len(field.Config.Links) == 0, field.Name)
+	}
+
+	// nor are the points filled into gaps
+	q.query.Options.Aggregations = nil
+	q.query.Options.GapFill = gapFillOptions{Mode: GapFillPrevious}
+	frames = q.processFrames(newFrames())
+	for _, field := range frames[0].Fields {
+		assert.True(t, field.Config == nil || len(field.Config.Links) == 0, field.Name)
+	}
+}
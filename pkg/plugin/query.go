@@ -3,19 +3,17 @@ package plugin
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	reductgo "github.com/reductstore/reduct-go"
-	model "github.com/reductstore/reduct-go/model"
 )
 
 // QueryData handles multiple queries and returns multiple responses.
@@ -25,8 +23,15 @@ import (
 func (d *ReductDatasource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	// create response struct
 	response := backend.NewQueryDataResponse()
+	planner := newScanPlanner()
+	var standalone []plannedQuery
 
-	// loop over queries and execute them individually.
+	var datasourceUID string
+	if settings := req.PluginContext.DataSourceInstanceSettings; settings != nil {
+		datasourceUID = settings.UID
+	}
+
+	// parse all queries first so that compatible ones can share a single scan.
 	for _, q := range req.Queries {
 		var qm reductQuery
 
@@ -43,6 +48,7 @@ func (d *ReductDatasource) QueryData(ctx context.Context, req *backend.QueryData
 		log.DefaultLogger.Debug(
 			"QueryData received",
 			"ref_id", q.RefID,
+			"query_type", qm.QueryType,
 			"bucket", qm.Bucket,
 			"entry", qm.Entry,
 			"entries", qm.Entries,
@@ -56,90 +62,167 @@ func (d *ReductDatasource) QueryData(ctx context.Context, req *backend.QueryData
 			entries = []string{qm.Entry}
 		}
 
-		if qm.Bucket == "" || len(entries) == 0 {
-			return &backend.QueryDataResponse{
-				Responses: map[string]backend.DataResponse{
-					q.RefID: backend.ErrDataResponse(backend.StatusBadRequest, "missing bucket or entries"),
-				},
-			}, nil
+		if !qm.QueryType.isValid() {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("unknown query type: %s", qm.QueryType))
+			continue
+		}
+
+		if (qm.Bucket == "" && qm.QueryType.requiresBucket()) || (len(entries) == 0 && qm.QueryType.requiresEntries()) {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, "missing bucket or entries")
+			continue
 		}
 		from := q.TimeRange.From.UTC()
 		to := q.TimeRange.To.UTC()
 
-		when := qm.Options.When
-		mode := qm.Options.Mode
+		shift, err := parseTimeShift(qm.Options.TimeShift)
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+		// the records are read from the past and shifted back when the frames are built
+		from = from.Add(-shift)
+		to = to.Add(-shift)
 
 		if from.After(to) {
-			return &backend.QueryDataResponse{
-				Responses: map[string]backend.DataResponse{
-					q.RefID: backend.ErrDataResponse(backend.StatusBadRequest, "from time is after to time"),
-				},
-			}, nil
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, "from time is after to time")
+			continue
 		}
 
-		options := reductgo.NewQueryOptionsBuilder().WithWhen(when)
-		if mode == ModeLabelOnly {
-			options.WithHead(true)
-		} else {
-			options.WithHead(false)
+		qm.Options.When, err = applyAdhocFilters(qm.Options.When, qm.AdhocFilters)
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+
+		if _, err := parseAggregations(qm.Options.Aggregations); err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
 		}
 
-		if !from.IsZero() {
-			options.WithStart(from.UnixMicro())
+		for _, t := range qm.Options.Transforms {
+			if err = t.validate(); err != nil {
+				break
+			}
 		}
-		if !to.IsZero() {
-			options.WithStop(to.UnixMicro())
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
 		}
-		res := d.query(ctx, req.PluginContext, qm.Bucket, entries, options.Build(), mode)
-		// save the response in a hashmap
-		// based on with RefID as identifier
-		response.Responses[q.RefID] = res
-	}
 
-	return response, nil
-}
+		expressions, err := compileExpressions(qm.Options.Expressions)
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+
+		if err := qm.Options.Align.validate(); err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+
+		if err := qm.Options.GapFill.validate(); err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+
+		if _, err := qm.Options.QueryLinks.expiry(); err != nil {
+			response.Responses[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+
+		pq := plannedQuery{
+			refID:    q.RefID,
+			query:    qm,
+			entries:  entries,
+			from:     from,
+			to:       to,
+			interval: q.Interval,
+			shift:    shift,
+
+			datasourceUID: datasourceUID,
+			expressions:   expressions,
+		}
+		if qm.QueryType.scansRecords() {
+			planner.add(pq)
+		} else {
+			standalone = append(standalone, pq)
+		}
+	}
 
-func (d *ReductDatasource) query(
-	ctx context.Context,
-	pCtx backend.PluginContext,
-	bucketName string,
-	entries []string,
-	options reductgo.QueryOptions,
-	mode ReductMode,
-) backend.DataResponse {
-	bucket, err := d.reductClient.GetBucket(ctx, bucketName)
-	if err != nil {
-		log.DefaultLogger.Error("Failed to get bucket", "error", err)
-		var apiErr model.APIError
-		errors.As(err, &apiErr)
-		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	// save the responses in a hashmap
+	// based on with RefID as identifier
+	for _, group := range planner.groups() {
+		for refID, res := range d.runScan(ctx, group) {
+			response.Responses[refID] = res
+		}
 	}
-	records, err := bucket.QueryMany(ctx, entries, &options)
-	if err != nil {
-		log.DefaultLogger.Error("Failed to query", "error", err)
-		var apiErr model.APIError
-		errors.As(err, &apiErr)
-		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	for _, pq := range standalone {
+		response.Responses[pq.refID] = d.runStandalone(ctx, pq)
 	}
 
-	frames := getFrames(records.Records(), mode)
-	return backend.DataResponse{
-		Frames: frames,
+	return response, nil
+}
+
+// runStandalone executes a query which doesn't read records and therefore can't share a scan.
+func (d *ReductDatasource) runStandalone(ctx context.Context, q plannedQuery) backend.DataResponse {
+	switch q.query.QueryType {
+	case QueryTypeInventory:
+		return d.queryInventory(ctx, q.query.Bucket, q.entries)
+	case QueryTypeCapacity:
+		return d.queryCapacity(ctx, q.query.Bucket)
+	case QueryTypeVariable:
+		return d.queryVariable(ctx, q)
+	case QueryTypeLatest:
+		return d.queryLatest(ctx, q)
+	case QueryTypeSnapshot:
+		return d.querySnapshot(ctx, q)
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("unknown query type: %s", q.query.QueryType))
 	}
 }
 
-func getFrames(records <-chan *reductgo.ReadableRecord, mode ReductMode) []*data.Frame {
+// getFrames builds a time series frame per entry and label or JSON path. The values of the groupBy
+// labels split the series further and are added to the frame names and the field labels.
+// The expressions add a series each, computed from the labels and the content of every record.
+// It fails if an expression is named like a label or JSON path series, but still drains the records.
+func getFrames(records <-chan *reductgo.ReadableRecord, mode ReductMode, groupBy []string, expressions []*expression) ([]*data.Frame, error) {
 	frames := make(map[string]*data.Frame)
 	labelKinds := make(map[string]reflect.Kind)
+	withLabels := mode == "" || mode == ModeLabelOnly || mode == ModeLabelAndContent
+	withContent := mode == ModeContentOnly || mode == ModeLabelAndContent
+	failures := make(map[string]*expressionFailure)
+	var collision error
 
 	for record := range records {
-		if mode == "" || mode == ModeLabelOnly || mode == ModeLabelAndContent {
-			processLabels(frames, labelKinds, record)
+		if collision != nil {
+			continue
+		}
+
+		// the body can be read only once, so it is decoded here for both the content and the expressions
+		var content map[string]any
+		if withContent || expressionsUseContent(expressions) {
+			if b, err := record.Read(); err == nil {
+				content, _ = decodeContent(b)
+			}
 		}
-		if mode == ModeContentOnly || mode == ModeLabelAndContent {
-			processContent(frames, record)
+
+		collision = expressionCollision(expressions, record, withLabels, withContent, content, groupBy)
+		if collision != nil {
+			continue
 		}
+
+		if withLabels {
+			processLabels(frames, labelKinds, record, groupBy)
+		}
+		if withContent {
+			appendContent(frames, labelKinds, record, content, groupBy)
+		}
+		processExpressions(frames, failures, record, content, expressions, groupBy)
 	}
+	if collision != nil {
+		return nil, collision
+	}
+	reportExpressionFailures(frames, failures)
 
 	result := make([]*data.Frame, 0, len(frames))
 	keys := make([]string, 0, len(frames))
@@ -150,13 +233,18 @@ func getFrames(records <-chan *reductgo.ReadableRecord, mode ReductMode) []*data
 	for _, k := range keys {
 		result = append(result, frames[k])
 	}
-	return result
+	return result, nil
 }
 
 // processLabels processes the labels of a record and appends them to the frames.
-func processLabels(frames map[string]*data.Frame, kindMap map[string]reflect.Kind, record *reductgo.ReadableRecord) {
+func processLabels(frames map[string]*data.Frame, kindMap map[string]reflect.Kind, record *reductgo.ReadableRecord, groupBy []string) {
 	entryName := record.Entry()
+	group := groupLabels(record, groupBy)
 	for key, labelValue := range record.Labels() {
+		// the group by labels identify the series instead of being one
+		if slices.Contains(groupBy, key) {
+			continue
+		}
 		frameKey := entryName + "/" + key
 
 		strValue := fmt.Sprintf("%v", labelValue)
@@ -181,64 +269,95 @@ func processLabels(frames map[string]*data.Frame, kindMap map[string]reflect.Kin
 			value = val
 		}
 
+		series := seriesKey(frameKey, group)
 		switch v := value.(type) {
 		case int64:
-			appendValue(frames, frameKey, record, v)
+			appendValue(frames, series, group, record, v)
 		case float64:
-			appendValue(frames, frameKey, record, v)
+			appendValue(frames, series, group, record, v)
 		case bool:
-			appendValue(frames, frameKey, record, v)
+			appendValue(frames, series, group, record, v)
 		case string:
-			appendValue(frames, frameKey, record, v)
+			appendValue(frames, series, group, record, v)
 		default:
-			appendValue(frames, frameKey, record, strValue)
+			appendValue(frames, series, group, record, strValue)
 		}
 	}
 }
 
-// processContent reads record body, parses JSON, flattens it, and appends values to frames.
-func processContent(
+// appendContent appends the flattened content of a record to the frames. Like labels, a value whose type
+// differs from the first value of its path is coerced to the type of the series or skipped.
+func appendContent(
 	frames map[string]*data.Frame,
+	kindMap map[string]reflect.Kind,
 	record *reductgo.ReadableRecord,
+	flat map[string]any,
+	groupBy []string,
 ) {
-	s, err := record.ReadAsString()
-	if err != nil || len(strings.TrimSpace(s)) == 0 {
-		return
-	}
-
-	b := []byte(s)
-	if !looksLikeJSON(b) {
-		return
-	}
-
-	var v any
-	if err := json.Unmarshal(b, &v); err != nil {
-		return
-	}
-
-	flat := map[string]any{}
-	flattenJSON("$", v, flat)
-
 	entryName := record.Entry()
+	group := groupLabels(record, groupBy)
 	for k, val := range flat {
 		// Create entry-prefixed frame key to separate time series per entry
-		frameKey := entryName + "/" + k
+		pathKey := entryName + "/" + k
+		frameKey := seriesKey(pathKey, group)
+
+		switch val.(type) {
+		case int64, float64, bool, string:
+		default:
+			val = fmt.Sprintf("%v", val)
+		}
+
+		kind := reflect.TypeOf(val).Kind()
+		initialType, ok := kindMap[pathKey]
+		if !ok {
+			kindMap[pathKey] = kind
+		} else if kind != initialType {
+			log.DefaultLogger.Debug("Type change detected", "key", pathKey, "from", initialType, "to", kind)
+			coerced, err := coerceToKind(fmt.Sprintf("%v", val), initialType)
+			if err != nil {
+				log.DefaultLogger.Debug("Failed to coerce value", "key", pathKey, "value", val, "error", err)
+				continue
+			}
+			val = coerced
+		}
+
 		switch v := val.(type) {
 		case int64:
-			appendValue(frames, frameKey, record, v)
+			appendValue(frames, frameKey, group, record, v)
 		case float64:
-			appendValue(frames, frameKey, record, v)
+			appendValue(frames, frameKey, group, record, v)
 		case bool:
-			appendValue(frames, frameKey, record, v)
+			appendValue(frames, frameKey, group, record, v)
 		case string:
-			appendValue(frames, frameKey, record, v)
-		default:
-			str := fmt.Sprintf("%v", val)
-			appendValue(frames, frameKey, record, str)
+			appendValue(frames, frameKey, group, record, v)
 		}
 	}
 }
 
+// groupLabels returns the values of the group by labels of a record. Labels missing in the record are left out.
+func groupLabels(record *reductgo.ReadableRecord, groupBy []string) data.Labels {
+	var labels data.Labels
+	for _, key := range groupBy {
+		value, ok := record.Labels()[key]
+		if !ok {
+			continue
+		}
+		if labels == nil {
+			labels = data.Labels{}
+		}
+		labels[key] = fmt.Sprintf("%v", value)
+	}
+	return labels
+}
+
+// seriesKey appends the group labels to a frame key, e.g. "robots/battery{robot_id=r1}".
+func seriesKey(key string, group data.Labels) string {
+	if len(group) == 0 {
+		return key
+	}
+	return key + "{" + group.String() + "}"
+}
+
 func looksLikeJSON(b []byte) bool {
 	for _, c := range b {
 		if c == ' ' || c == '\n' || c == '\t' || c == '\r' {
@@ -265,7 +384,7 @@ func flattenJSON(prefix string, v any, out map[string]any) {
 }
 
 // appendValue appends a value to the frame for the given key.
-func appendValue[V float64 | int64 | bool | string](frames map[string]*data.Frame, key string, record *reductgo.ReadableRecord, val V) {
+func appendValue[V float64 | int64 | bool | string](frames map[string]*data.Frame, key string, labels data.Labels, record *reductgo.ReadableRecord, val V) {
 	// Check if frame for this label already exists
 	if frame, exists := frames[key]; exists {
 		// Append new value to existing frame
@@ -275,7 +394,7 @@ func appendValue[V float64 | int64 | bool | string](frames map[string]*data.Fram
 		// Create a new frame for this label
 		frame = data.NewFrame(key,
 			data.NewField("time", nil, []time.Time{time.UnixMicro(record.Time())}),
-			data.NewField("value", nil, []V{val}),
+			data.NewField("value", labels, []V{val}),
 		)
 
 		frame.Meta = &data.FrameMeta{
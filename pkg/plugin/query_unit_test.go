@@ -1,15 +1,19 @@
 package plugin
 
 import (
+	"context"
+	"encoding/json"
 	"io"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	reductgo "github.com/reductstore/reduct-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProcessLabels(t *testing.T) {
@@ -38,7 +42,7 @@ func TestProcessLabels(t *testing.T) {
 
 	labelInitialType := make(map[string]reflect.Kind)
 	for _, rec := range records {
-		processLabels(frames, labelInitialType, rec)
+		processLabels(frames, labelInitialType, rec, nil)
 	}
 
 	assert.Len(t, frames, 4)
@@ -68,7 +72,7 @@ func TestProcessLabels(t *testing.T) {
 	assert.Equal(t, data.NewField("value", nil, []string{"hello", "world", "stay"}), strFrame.Fields[1])
 }
 
-func TestProcessContent_PreservesJSONTypes(t *testing.T) {
+func TestAppendContent_PreservesJSONTypes(t *testing.T) {
 	frames := make(map[string]*data.Frame)
 
 	jsonContent1 := `{
@@ -107,8 +111,13 @@ func TestProcessContent_PreservesJSONTypes(t *testing.T) {
 		"application/json",
 	)
 
-	processContent(frames, record1)
-	processContent(frames, record2)
+	for _, record := range []*reductgo.ReadableRecord{record1, record2} {
+		b, err := record.Read()
+		require.NoError(t, err)
+		content, ok := decodeContent(b)
+		require.True(t, ok)
+		appendContent(frames, make(map[string]reflect.Kind), record, content, nil)
+	}
 
 	// Frame keys are now entry-prefixed
 	strNumFrame, exists := frames["json-entry/$.str_number"]
@@ -141,3 +150,81 @@ func TestProcessContent_PreservesJSONTypes(t *testing.T) {
 	assert.Equal(t, float64(42), countFrame.Fields[1].At(0))
 	assert.Equal(t, float64(84), countFrame.Fields[1].At(1))
 }
+
+func TestGetFrames_GroupBy(t *testing.T) {
+	records := make(chan *reductgo.ReadableRecord, 3)
+	records <- newHeadRecord("robots", 1, 0, reductgo.LabelMap{"robot_id": "r1", "battery": "90"})
+	records <- newHeadRecord("robots", 2, 0, reductgo.LabelMap{"robot_id": "r2", "battery": "80"})
+	records <- newHeadRecord("robots", 3, 0, reductgo.LabelMap{"robot_id": "r1", "battery": "89"})
+	close(records)
+
+	frames, err := getFrames(records, ModeLabelOnly, []string{"robot_id", "site"}, nil)
+	require.NoError(t, err)
+
+	assert.Len(t, frames, 2)
+	assert.Equal(t, "robots/battery{robot_id=r1}", frames[0].Name)
+	assert.Equal(t, data.Labels{"robot_id": "r1"}, frames[0].Fields[1].Labels)
+	assert.Equal(t, []int64{90, 89}, []int64{frames[0].Fields[1].At(0).(int64), frames[0].Fields[1].At(1).(int64)})
+	assert.Equal(t, "robots/battery{robot_id=r2}", frames[1].Name)
+	assert.Equal(t, data.Labels{"robot_id": "r2"}, frames[1].Fields[1].Labels)
+}
+
+func TestQueryData_ValidationErrorsPerQuery(t *testing.T) {
+	queries := map[string]string{
+		"A": `{"bucket": "b", "entry": "e", "options": {"expressions": [{"name": "x", "expr": "1 +"}]}}`,
+		"B": `{"bucket": "b", "entry": "e", "options": {"expressions": [{"name": "", "expr": "1"}]}}`,
+		"C": `{"bucket": "b", "entry": "e", "options": {"aggregations": ["median"]}}`,
+		"D": `{"bucket": "b", "entry": "e", "options": {"transforms": [{"type": "moving_average"}]}}`,
+		"E": `{"bucket": "b", "entry": "e", "options": {"gapFill": {"mode": "spline"}}}`,
+		"F": `{"bucket": "b", "entry": "e", "options": {"align": {"enabled": true, "match": "next"}}}`,
+		"G": `{"bucket": "b", "entry": "e", "options": {"queryLinks": {"enabled": true, "expiry": "soon"}}}`,
+		"H": `{"bucket": "b", "entry": "e", "options": {"timeShift": "yesterday"}}`,
+		"I": `{"bucket": "b", "entry": "e", "adhocFilters": [{"key": "mode", "operator": "<>", "value": "auto"}]}`,
+		"J": `{"queryType": "unknown", "bucket": "b"}`,
+		"K": `{"bucket": "b", "entry": "e", "options": {"expressions": [{"name": "x", "expr": "1"}, {"name": "x", "expr": "2"}]}}`,
+	}
+
+	req := &backend.QueryDataRequest{}
+	for refID, query := range queries {
+		req.Queries = append(req.Queries, backend.DataQuery{
+			RefID:     refID,
+			TimeRange: backend.TimeRange{From: time.UnixMilli(0), To: time.UnixMilli(1000)},
+			JSON:      json.RawMessage(query),
+		})
+	}
+
+	resp, err := (&ReductDatasource{}).QueryData(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Responses, len(queries))
+	for refID := range queries {
+		assert.Equal(t, backend.StatusBadRequest, resp.Responses[refID].Status, refID)
+		assert.Error(t, resp.Responses[refID].Error, refID)
+	}
+}
+
+func TestQueryData_InvalidQueryKeepsOtherResponses(t *testing.T) {
+	newQuery := func(refID, query string, from, to int64) backend.DataQuery {
+		return backend.DataQuery{
+			RefID:     refID,
+			TimeRange: backend.TimeRange{From: time.UnixMilli(from), To: time.UnixMilli(to)},
+			JSON:      json.RawMessage(query),
+		}
+	}
+	valid := newQuery("valid", `{"queryType": "variable", "options": {"variable": {"kind": "buckets"}}}`, 0, 1000)
+
+	for name, invalid := range map[string]backend.DataQuery{
+		"missing entries": newQuery("invalid", `{"bucket": "b"}`, 0, 1000),
+		"from after to":   newQuery("invalid", `{"bucket": "b", "entry": "e"}`, 1000, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ds := &ReductDatasource{reductClient: stubClient{}}
+			resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{invalid, valid},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, backend.StatusBadRequest, resp.Responses["invalid"].Status)
+			assert.NoError(t, resp.Responses["valid"].Error)
+			require.Len(t, resp.Responses["valid"].Frames, 1)
+		})
+	}
+}
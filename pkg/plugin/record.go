@@ -0,0 +1,268 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/reductstore/reduct-go/model"
+)
+
+const (
+	// defaultPreviewSize is the size cap of a previewed body when the request doesn't set one.
+	defaultPreviewSize = 1 << 20
+	// maxPreviewSize protects the plugin from loading huge records into memory.
+	maxPreviewSize = 16 << 20
+)
+
+// Output formats of the record resource.
+const (
+	// PreviewRaw returns the body as is with the content type of the record.
+	PreviewRaw = "raw"
+	// PreviewPretty returns the record metadata with a rendered body as JSON.
+	PreviewPretty = "pretty"
+)
+
+// Body encodings of a pretty preview.
+const (
+	EncodingJSON   = "json"
+	EncodingText   = "text"
+	EncodingBase64 = "base64"
+)
+
+// recordRequest selects a single record. Time is in microseconds unless Unit is "ms", in which case
+// the first record of that millisecond is returned. This is what data links can provide.
+type recordRequest struct {
+	Bucket  string `json:"bucket"`
+	Entry   string `json:"entry"`
+	Time    int64  `json:"time"`
+	Unit    string `json:"unit"`
+	Format  string `json:"format"`
+	MaxSize int64  `json:"maxSize"`
+	// Shift is subtracted from Time, in the same unit, e.g. the time shift of the series linking to the record.
+	Shift int64 `json:"shift"`
+}
+
+// recordPreview is a record with its body rendered for display.
+type recordPreview struct {
+	recordHeader
+	Encoding  string `json:"encoding"`
+	Body      string `json:"body"`
+	Truncated bool   `json:"truncated"`
+}
+
+// parseRecordRequest reads the request from the query string of a GET request or the JSON body.
+func parseRecordRequest(req *backend.CallResourceRequest) (recordRequest, error) {
+	var payload recordRequest
+	if req.Method == http.MethodGet {
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return payload, err
+		}
+		params := u.Query()
+		payload.Bucket = params.Get("bucket")
+		payload.Entry = params.Get("entry")
+		payload.Unit = params.Get("unit")
+		payload.Format = params.Get("format")
+		if payload.Time, err = strconv.ParseInt(params.Get("time"), 10, 64); err != nil {
+			return payload, fmt.Errorf("invalid time: %w", err)
+		}
+		if s := params.Get("maxSize"); s != "" {
+			if payload.MaxSize, err = strconv.ParseInt(s, 10, 64); err != nil {
+				return payload, fmt.Errorf("invalid maxSize: %w", err)
+			}
+		}
+		if s := params.Get("shift"); s != "" {
+			if payload.Shift, err = strconv.ParseInt(s, 10, 64); err != nil {
+				return payload, fmt.Errorf("invalid shift: %w", err)
+			}
+		}
+	} else if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return payload, err
+	}
+
+	if payload.Bucket == "" || payload.Entry == "" {
+		return payload, errors.New("missing 'bucket' or 'entry'")
+	}
+	payload.Time -= payload.Shift
+	if payload.Format == "" {
+		payload.Format = PreviewPretty
+	}
+	if payload.Format != PreviewRaw && payload.Format != PreviewPretty {
+		return payload, fmt.Errorf("unknown format '%s'", payload.Format)
+	}
+	if payload.MaxSize <= 0 {
+		payload.MaxSize = defaultPreviewSize
+	}
+	payload.MaxSize = min(payload.MaxSize, maxPreviewSize)
+	return payload, nil
+}
+
+// renderBody picks the encoding of a body from its content type and content. pretty indents JSON.
+func renderBody(body []byte, contentType string, pretty bool) (string, string) {
+	if strings.Contains(contentType, "json") || (contentType == "" && looksLikeJSON(body)) {
+		if !pretty {
+			return EncodingJSON, string(body)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, body, "", "  "); err == nil {
+			return EncodingJSON, out.String()
+		}
+	}
+
+	textual := strings.HasPrefix(contentType, "text/") || contentType == "" || contentType == "application/octet-stream"
+	if textual && utf8.Valid(body) {
+		return EncodingText, string(body)
+	}
+	return EncodingBase64, base64.StdEncoding.EncodeToString(body)
+}
+
+// readRecord fetches the record selected by the request.
+func (d *ReductDatasource) readRecord(ctx context.Context, payload recordRequest) (*reductgo.ReadableRecord, error) {
+	bucket, err := d.reductClient.GetBucket(ctx, payload.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Unit != "ms" {
+		return bucket.BeginRead(ctx, payload.Entry, &payload.Time)
+	}
+
+	start := payload.Time * 1000
+	options := reductgo.NewQueryOptionsBuilder().
+		WithWhen(map[string]any{"$limit": 1}).
+		WithStart(start).
+		WithStop(start + 1000).
+		Build()
+	result, err := bucket.Query(ctx, payload.Entry, &options)
+	if err != nil {
+		return nil, err
+	}
+
+	record, ok := <-result.Records()
+	if !ok {
+		return nil, model.APIError{Status: http.StatusNotFound, Message: "record not found"}
+	}
+	return record, nil
+}
+
+func (d *ReductDatasource) handleRecord(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	payload, err := parseRecordRequest(req)
+	if err != nil {
+		log.DefaultLogger.Warn("Invalid record request", "error", err)
+		return sendError(sender, http.StatusBadRequest, err.Error())
+	}
+
+	record, err := d.readRecord(ctx, payload)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to read record", "bucket", payload.Bucket, "entry", payload.Entry, "time", payload.Time, "error", err)
+		var apiErr model.APIError
+		if errors.As(err, &apiErr) && apiErr.Status > 0 {
+			return sendError(sender, apiErr.Status, apiErr.Message)
+		}
+		return sendError(sender, http.StatusInternalServerError, fmt.Sprintf("error reading record: %v", err))
+	}
+
+	truncated := record.Size() > payload.MaxSize
+	if truncated && payload.Format == PreviewRaw {
+		return sendError(sender, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("record is larger than %d bytes", payload.MaxSize))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(record.Stream(), payload.MaxSize))
+	if err != nil {
+		log.DefaultLogger.Error("Failed to read record body", "entry", record.Entry(), "time", record.Time(), "error", err)
+		return sendError(sender, http.StatusInternalServerError, "error reading record body")
+	}
+
+	if payload.Format == PreviewRaw {
+		contentType := record.ContentType()
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusOK,
+			Headers: map[string][]string{"Content-Type": {contentType}},
+			Body:    body,
+		})
+	}
+
+	preview := recordPreview{
+		recordHeader: recordHeader{
+			Entry:       record.Entry(),
+			Time:        record.Time(),
+			Size:        record.Size(),
+			ContentType: record.ContentType(),
+			Labels:      record.Labels(),
+		},
+		Truncated: truncated,
+	}
+	// a truncated JSON body can't be indented
+	preview.Encoding, preview.Body = renderBody(body, record.ContentType(), !truncated)
+	return sendJSON(sender, http.StatusOK, preview)
+}
+
+// recordLinkURL returns the URL of the raw record behind a point of a time series frame.
+// Grafana only interpolates the point time in milliseconds, so the link selects the first record of that millisecond.
+func recordLinkURL(datasourceUID, bucket, entry string, shift time.Duration) string {
+	params := url.Values{}
+	params.Set("bucket", bucket)
+	params.Set("entry", entry)
+	params.Set("format", PreviewRaw)
+	if shift > 0 {
+		params.Set("shift", strconv.FormatInt(shift.Milliseconds(), 10))
+	}
+	params.Set("unit", "ms")
+	return fmt.Sprintf("/api/datasources/uid/%s/resources/record?%s&time=${__value.time}",
+		url.PathEscape(datasourceUID), params.Encode())
+}
+
+// frameEntry returns the entry name of a frame named "<entry>/<label>" or "<entry>/$.<path>",
+// optionally followed by the group labels.
+func frameEntry(name string) string {
+	if i := strings.Index(name, "{"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.Index(name, "/$."); i >= 0 {
+		return name[:i]
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// addRecordLinks attaches a data link to the record of every point of the time series frames.
+// The points of a time shifted query are drawn later than their records, so the links take the shift back.
+func addRecordLinks(frames []*data.Frame, datasourceUID, bucket string, shift time.Duration) {
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 {
+			continue
+		}
+		link := data.DataLink{
+			Title:       "Open record",
+			URL:         recordLinkURL(datasourceUID, bucket, frameEntry(frame.Name), shift),
+			TargetBlank: true,
+		}
+		for _, field := range frame.Fields[1:] {
+			if field.Config == nil {
+				field.Config = &data.FieldConfig{}
+			}
+			field.Config.Links = append(field.Config.Links, link)
+		}
+	}
+}
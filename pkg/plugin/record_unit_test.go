@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecordRequest(t *testing.T) {
+	payload, err := parseRecordRequest(&backend.CallResourceRequest{
+		Method: http.MethodGet,
+		URL:    "record?bucket=b&entry=cam%2F1&time=1700&unit=ms&format=raw&maxSize=99999999",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, recordRequest{
+		Bucket: "b", Entry: "cam/1", Time: 1700, Unit: "ms", Format: PreviewRaw, MaxSize: maxPreviewSize,
+	}, payload)
+
+	payload, err = parseRecordRequest(&backend.CallResourceRequest{
+		Method: http.MethodPost,
+		Body:   []byte(`{"bucket":"b","entry":"e","time":5}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PreviewPretty, payload.Format)
+	assert.Equal(t, int64(defaultPreviewSize), payload.MaxSize)
+
+	_, err = parseRecordRequest(&backend.CallResourceRequest{Method: http.MethodPost, Body: []byte(`{"bucket":"b"}`)})
+	assert.Error(t, err)
+
+	_, err = parseRecordRequest(&backend.CallResourceRequest{
+		Method: http.MethodPost,
+		Body:   []byte(`{"bucket":"b","entry":"e","format":"html"}`),
+	})
+	assert.EqualError(t, err, "unknown format 'html'")
+}
+
+func TestRenderBody(t *testing.T) {
+	encoding, body := renderBody([]byte(`{"a":1}`), "application/json", true)
+	assert.Equal(t, EncodingJSON, encoding)
+	assert.Equal(t, "{\n  \"a\": 1\n}", body)
+
+	encoding, body = renderBody([]byte(`{"a":1}`), "", false)
+	assert.Equal(t, EncodingJSON, encoding)
+	assert.Equal(t, `{"a":1}`, body)
+
+	encoding, body = renderBody([]byte("hello"), "text/plain", true)
+	assert.Equal(t, EncodingText, encoding)
+	assert.Equal(t, "hello", body)
+
+	encoding, body = renderBody([]byte{0x89, 'P', 'N', 'G'}, "image/png", true)
+	assert.Equal(t, EncodingBase64, encoding)
+	assert.Equal(t, "iVBORw==", body)
+}
+
+func TestFrameEntry(t *testing.T) {
+	assert.Equal(t, "cam", frameEntry("cam/score"))
+	assert.Equal(t, "robots/arm", frameEntry("robots/arm/$.a/b"))
+	assert.Equal(t, "cam", frameEntry("cam"))
+	assert.Equal(t, "robots", frameEntry("robots/battery{site=a/b}"))
+}
+
+func TestAddRecordLinks(t *testing.T) {
+	frame := data.NewFrame("cam/score",
+		data.NewField("time", nil, []time.Time{time.UnixMilli(1)}),
+		data.NewField("value", nil, []int64{1}),
+	)
+	addRecordLinks([]*data.Frame{frame, data.NewFrame("empty")}, "ds-uid", "my bucket", 0)
+
+	assert.Nil(t, frame.Fields[0].Config)
+	assert.Equal(t, []data.DataLink{{
+		Title:       "Open record",
+		URL:         "/api/datasources/uid/ds-uid/resources/record?bucket=my+bucket&entry=cam&format=raw&unit=ms&time=${__value.time}",
+		TargetBlank: true,
+	}}, frame.Fields[1].Config.Links)
+}
+
+func TestAddRecordLinks_TimeShift(t *testing.T) {
+	frame := data.NewFrame("cam/score",
+		data.NewField("time", nil, []time.Time{time.UnixMilli(1)}),
+		data.NewField("value", nil, []int64{1}),
+	)
+	addRecordLinks([]*data.Frame{frame}, "ds-uid", "b", 24*time.Hour)
+	assert.Equal(t,
+		"/api/datasources/uid/ds-uid/resources/record?bucket=b&entry=cam&format=raw&shift=86400000&unit=ms&time=${__value.time}",
+		frame.Fields[1].Config.Links[0].URL)
+
+	payload, err := parseRecordRequest(&backend.CallResourceRequest{
+		Method: http.MethodGet,
+		URL:    "record?bucket=b&entry=cam&format=raw&shift=86400000&unit=ms&time=86400001",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), payload.Time)
+}
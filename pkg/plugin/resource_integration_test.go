@@ -133,6 +133,47 @@ func TestCallResource_ValidateCondition_Valid(t *testing.T) {
 	assert.Equal(t, true, parsed["valid"])
 }
 
+func TestCallResource_ValidateCondition_Wildcard(t *testing.T) {
+	ds := newTestDatasource(t)
+
+	client := newAdminClient()
+	bucket, _ := client.CreateOrGetBucket(context.Background(), "cr-val-bucket", nil)
+	bucket.BeginWrite(context.Background(), "cam-1", nil).Write("123")
+	bucket.BeginWrite(context.Background(), "cam-2", nil).Write("123")
+
+	body := []byte(`{
+		"bucket": "cr-val-bucket",
+		"entries": ["cam-*", "radar-*"],
+		"condition": {"&sensor":{"$eq":"ok"}}
+	}`)
+
+	var resp backend.CallResourceResponse
+	sender := &testSender{resp: &resp}
+
+	err := ds.CallResource(
+		context.Background(),
+		&backend.CallResourceRequest{
+			Path: "validateCondition",
+			Body: body,
+		},
+		sender,
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.Status)
+
+	var parsed struct {
+		Valid             bool              `json:"valid"`
+		Entries           []entryValidation `json:"entries"`
+		UnmatchedPatterns []string          `json:"unmatched_patterns"`
+	}
+	json.Unmarshal(resp.Body, &parsed)
+
+	assert.True(t, parsed.Valid)
+	assert.Equal(t, []entryValidation{{Entry: "cam-1", Valid: true}, {Entry: "cam-2", Valid: true}}, parsed.Entries)
+	assert.Equal(t, []string{"radar-*"}, parsed.UnmatchedPatterns)
+}
+
 func TestCallResource_ValidateCondition_InvalidJSON(t *testing.T) {
 	ds := newTestDatasource(t)
 
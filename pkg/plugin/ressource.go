@@ -36,6 +36,38 @@ func (d *ReductDatasource) CallResource(ctx context.Context, req *backend.CallRe
 		log.DefaultLogger.Debug("Received serverInfo")
 		return d.handleServerInfo(ctx, sender)
 
+	case "tagKeys":
+		log.DefaultLogger.Debug("Received tagKeys", "body", req.Body)
+		return d.handleTagKeys(ctx, req, sender)
+
+	case "tagValues":
+		log.DefaultLogger.Debug("Received tagValues", "body", req.Body)
+		return d.handleTagValues(ctx, req, sender)
+
+	case "listLabels":
+		log.DefaultLogger.Debug("Received listLabels", "body", req.Body)
+		return d.handleListLabels(ctx, req, sender)
+
+	case "contentSchema":
+		log.DefaultLogger.Debug("Received contentSchema", "body", req.Body)
+		return d.handleContentSchema(ctx, req, sender)
+
+	case "explainQuery":
+		log.DefaultLogger.Debug("Received explainQuery", "body", req.Body)
+		return d.handleExplainQuery(ctx, req, sender)
+
+	case "lintCondition":
+		log.DefaultLogger.Debug("Received lintCondition", "body", req.Body)
+		return d.handleLintCondition(ctx, req, sender)
+
+	case "record":
+		log.DefaultLogger.Debug("Received record", "url", req.URL, "body", req.Body)
+		return d.handleRecord(ctx, req, sender)
+
+	case "export":
+		log.DefaultLogger.Debug("Received export", "body", req.Body)
+		return d.handleExport(ctx, req, sender)
+
 	default:
 		log.DefaultLogger.Warn("Unknown resource path", "path", req.Path)
 		return sender.Send(&backend.CallResourceResponse{
@@ -138,134 +170,155 @@ func (d *ReductDatasource) handleListEntries(ctx context.Context, req *backend.C
 	})
 }
 
+// entryValidation is the validation result of a condition for a single entry.
+type entryValidation struct {
+	Entry string `json:"entry"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// validationErrorMessage returns the server message of a failed validation query.
+func validationErrorMessage(err error) string {
+	var apiErr model.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Message
+	}
+	return "Query validation failed"
+}
+
+// validateEntries runs the condition with $limit: 1 over the entries in a single QueryMany call. Only if
+// it fails, every entry is validated on its own to find the ones the condition doesn't work for.
+// The queries are cancelled on return, so their records are never read.
+func validateEntries(ctx context.Context, bucket reductgo.Bucket, names []string, condition map[string]any) []entryValidation {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	query := func(names []string) error {
+		options := reductgo.NewQueryOptionsBuilder().WithWhen(condition).Build()
+		_, err := bucket.QueryMany(ctx, names, &options)
+		return err
+	}
+
+	results := make([]entryValidation, 0, len(names))
+	if err := query(names); err == nil {
+		for _, name := range names {
+			results = append(results, entryValidation{Entry: name, Valid: true})
+		}
+		return results
+	}
+
+	for _, name := range names {
+		result := entryValidation{Entry: name, Valid: true}
+		if err := query([]string{name}); err != nil {
+			log.DefaultLogger.Debug("Query validation failed", "entry", name, "error", err)
+			result.Valid = false
+			result.Error = validationErrorMessage(err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
 func (d *ReductDatasource) handleValidateCondition(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
 	var payload struct {
-		Bucket    string `json:"bucket"`
-		Entry     string `json:"entry"`
-		Condition any    `json:"condition"`
+		Bucket    string   `json:"bucket"`
+		Entry     string   `json:"entry"`
+		Entries   []string `json:"entries"`
+		Condition any      `json:"condition"`
 	}
 
-	body, err := io.ReadAll(bytes.NewReader(req.Body))
-	if err != nil {
-		log.DefaultLogger.Error("Failed to read request body", "error", err)
-		errorResp := map[string]string{"error": "invalid request body"}
-		errorJson, _ := json.Marshal(errorResp)
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusBadRequest,
-			Body:   errorJson,
-		})
+	err := json.Unmarshal(req.Body, &payload)
+	if err != nil || payload.Bucket == "" || (payload.Entry == "" && len(payload.Entries) == 0) {
+		log.DefaultLogger.Warn("Missing or invalid bucket/entries in request")
+		return sendError(sender, http.StatusBadRequest, "missing or invalid 'bucket' or 'entries' in request")
 	}
 
-	err = json.Unmarshal(body, &payload)
-	if err != nil || payload.Bucket == "" || payload.Entry == "" {
-		log.DefaultLogger.Warn("Missing or invalid bucket/entry in request")
-		errorResp := map[string]string{"error": "missing or invalid 'bucket' or 'entry' in request"}
-		errorJson, _ := json.Marshal(errorResp)
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusBadRequest,
-			Body:   errorJson,
-		})
+	patterns := payload.Entries
+	if len(patterns) == 0 {
+		patterns = []string{payload.Entry}
 	}
 
-	// Create modified condition with $limit: 1 for validation
-	conditionMap, err := parseAndNormalizeCondition(payload.Condition)
+	response := struct {
+		Valid             bool              `json:"valid"`
+		Error             string            `json:"error,omitempty"`
+		Entries           []entryValidation `json:"entries"`
+		UnmatchedPatterns []string          `json:"unmatched_patterns"`
+	}{
+		Entries:           []entryValidation{},
+		UnmatchedPatterns: []string{},
+	}
+
+	condition, err := parseAndNormalizeCondition(payload.Condition)
 	if err != nil {
-		response := map[string]any{
-			"valid": false,
-			"error": err.Error(),
-		}
-		resp, _ := json.Marshal(response)
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusOK,
-			Body:   resp,
-		})
+		response.Error = err.Error()
+		return sendJSON(sender, http.StatusOK, response)
 	}
 
-	// Add or override $limit to 1 for validation
-	conditionMap["$limit"] = 1
-	modifiedCondition := conditionMap
+	// one record is enough to validate the condition
+	condition["$limit"] = 1
 
-	// Test the condition by running a query with it
 	bucket, err := d.reductClient.GetBucket(ctx, payload.Bucket)
 	if err != nil {
 		log.DefaultLogger.Error("Failed to get bucket", "bucket", payload.Bucket, "error", err)
+		response.Error = fmt.Sprintf("Failed to access bucket '%s'", payload.Bucket)
 		var apiErr model.APIError
-		errorMsg := fmt.Sprintf("Failed to access bucket '%s'", payload.Bucket)
 		if errors.As(err, &apiErr) {
-			errorMsg = apiErr.Message
+			response.Error = apiErr.Message
 		}
-		response := map[string]any{
-			"valid": false,
-			"error": errorMsg,
-		}
-		resp, _ := json.Marshal(response)
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusOK,
-			Body:   resp,
-		})
+		return sendJSON(sender, http.StatusOK, response)
 	}
 
-	// Create query options with the modified condition
-	options := reductgo.NewQueryOptionsBuilder().WithWhen(modifiedCondition).Build()
-
-	// Try to execute the query to validate the condition
-	records, err := bucket.Query(ctx, payload.Entry, &options)
+	entries, err := bucket.GetEntries(ctx)
 	if err != nil {
-		log.DefaultLogger.Debug("Query validation failed", "error", err)
-		var apiErr model.APIError
-		errorMsg := "Query validation failed"
-		if errors.As(err, &apiErr) {
-			errorMsg = apiErr.Message
-		}
-		response := map[string]any{
-			"valid": false,
-			"error": errorMsg,
-		}
-		resp, _ := json.Marshal(response)
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusOK,
-			Body:   resp,
-		})
+		log.DefaultLogger.Error("Failed to list entries", "error", err)
+		return sendError(sender, http.StatusInternalServerError, "error getting entries")
 	}
 
-	// Close the records channel to clean up
-	go func() {
-		for range records.Records() {
-			// Drain the channel
-		}
-	}()
+	matched, unmatched := resolveEntries(entries, patterns)
+	response.UnmatchedPatterns = unmatched
+	if len(matched) == 0 {
+		response.Error = fmt.Sprintf("No entries match %s", strings.Join(patterns, ", "))
+		return sendJSON(sender, http.StatusOK, response)
+	}
 
-	// If we get here, the condition is valid
-	response := map[string]any{
-		"valid": true,
+	names := make([]string, 0, len(matched))
+	for _, entry := range matched {
+		names = append(names, entry.Name)
 	}
-	resp, err := json.Marshal(response)
-	if err != nil {
-		errorResp := map[string]string{"error": "failed to marshal validation response"}
-		errorJson, _ := json.Marshal(errorResp)
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusInternalServerError,
-			Body:   errorJson,
-		})
+
+	response.Valid = true
+	response.Entries = validateEntries(ctx, bucket, names, condition)
+	for _, result := range response.Entries {
+		if !result.Valid {
+			response.Valid = false
+			response.Error = fmt.Sprintf("%s: %s", result.Entry, result.Error)
+			break
+		}
 	}
 
-	return sender.Send(&backend.CallResourceResponse{
-		Status: http.StatusOK,
-		Body:   resp,
-	})
+	return sendJSON(sender, http.StatusOK, response)
 }
 
 func parseAndNormalizeCondition(condition any) (map[string]any, error) {
 	// Default interval used for validating the condition
 	const defaultInterval = "1s"
 
+	parsed, err := parseCondition(condition)
+	if err != nil {
+		return nil, err
+	}
+	return replaceIntervalMacros(parsed, defaultInterval).(map[string]any), nil
+}
+
+// parseCondition accepts a condition as an object or a JSON string and returns it as a map.
+func parseCondition(condition any) (map[string]any, error) {
 	if condition == nil {
 		return map[string]any{}, nil
 	}
 
 	switch v := condition.(type) {
 	case map[string]any:
-		return replaceIntervalMacros(v, defaultInterval).(map[string]any), nil
+		return v, nil
 	case string:
 		trimmed := strings.TrimSpace(v)
 		if trimmed == "" {
@@ -283,7 +336,7 @@ func parseAndNormalizeCondition(condition any) (map[string]any, error) {
 			return nil, fmt.Errorf("invalid JSON syntax: expected object for condition")
 		}
 
-		return replaceIntervalMacros(parsedMap, defaultInterval).(map[string]any), nil
+		return parsedMap, nil
 	default:
 		return nil, fmt.Errorf("invalid condition: expected object or JSON string")
 	}
@@ -351,3 +404,24 @@ func (d *ReductDatasource) handleServerInfo(ctx context.Context, sender backend.
 		Body:   resp,
 	})
 }
+
+// sendError sends a JSON error body with the given status.
+func sendError(sender backend.CallResourceResponseSender, status int, message string) error {
+	errorJson, _ := json.Marshal(map[string]string{"error": message})
+	return sender.Send(&backend.CallResourceResponse{
+		Status: status,
+		Body:   errorJson,
+	})
+}
+
+// sendJSON marshals the value and sends it with the given status.
+func sendJSON(sender backend.CallResourceResponseSender, status int, value any) error {
+	resp, err := json.Marshal(value)
+	if err != nil {
+		return sendError(sender, http.StatusInternalServerError, "failed to marshal response")
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status: status,
+		Body:   resp,
+	})
+}
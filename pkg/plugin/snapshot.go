@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	model "github.com/reductstore/reduct-go/model"
+)
+
+// querySnapshot returns a table with the labels of the newest record of every entry matching the query patterns.
+func (d *ReductDatasource) querySnapshot(ctx context.Context, q plannedQuery) backend.DataResponse {
+	bucket, err := d.reductClient.GetBucket(ctx, q.query.Bucket)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to get bucket", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	records, err := latestRecords(ctx, bucket, q.entries, false)
+	if err != nil {
+		log.DefaultLogger.Error("Failed to read latest records", "error", err)
+		var apiErr model.APIError
+		errors.As(err, &apiErr)
+		return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+	}
+
+	return backend.DataResponse{
+		Frames: []*data.Frame{getSnapshotFrame(records, time.Now())},
+	}
+}
+
+// getSnapshotFrame builds a table frame with one row per record and a column per label key. Age is the time
+// in seconds since the record relative to now. A column has the type of its label values as far as all of them
+// can be converted to it, and the cells of entries without the label are null.
+func getSnapshotFrame(records []latestRecord, now time.Time) *data.Frame {
+	names := make([]string, len(records))
+	times := make([]time.Time, len(records))
+	ages := make([]float64, len(records))
+	types := make(map[string]string)
+
+	for i, record := range records {
+		names[i] = record.entry
+		times[i] = time.UnixMicro(record.time)
+		ages[i] = now.Sub(times[i]).Seconds()
+		for key, value := range record.labels {
+			types[key] = mergeLabelTypes(types[key], labelTypeOf(fmt.Sprintf("%v", value)))
+		}
+	}
+
+	fields := []*data.Field{
+		data.NewField("entry", nil, names),
+		data.NewField("time", nil, times),
+		newUnitField("age", "s", ages),
+	}
+	for _, key := range sortedKeys(types) {
+		fields = append(fields, newLabelColumn(key, types[key], records))
+	}
+
+	frame := data.NewFrame("snapshot", fields...)
+	frame.Meta = &data.FrameMeta{
+		Type: data.FrameTypeTable,
+	}
+	return frame
+}
+
+// newLabelColumn returns a nullable field with the values of a label converted to the label type.
+func newLabelColumn(key, labelType string, records []latestRecord) *data.Field {
+	var values any
+	switch labelType {
+	case LabelTypeInt:
+		values = make([]*int64, len(records))
+	case LabelTypeFloat:
+		values = make([]*float64, len(records))
+	case LabelTypeBool:
+		values = make([]*bool, len(records))
+	default:
+		values = make([]*string, len(records))
+	}
+	field := data.NewField(key, nil, values)
+
+	for i, record := range records {
+		value, ok := record.labels[key]
+		if !ok {
+			continue
+		}
+
+		str := fmt.Sprintf("%v", value)
+		switch labelType {
+		case LabelTypeInt:
+			if v, err := strconv.ParseInt(str, 10, 64); err == nil {
+				field.Set(i, &v)
+			}
+		case LabelTypeFloat:
+			if v, err := strconv.ParseFloat(str, 64); err == nil {
+				field.Set(i, &v)
+			}
+		case LabelTypeBool:
+			if v, err := strconv.ParseBool(str); err == nil {
+				field.Set(i, &v)
+			}
+		default:
+			field.Set(i, &str)
+		}
+	}
+	return field
+}
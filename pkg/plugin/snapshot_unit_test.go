@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSnapshotFrame(t *testing.T) {
+	now := time.UnixMicro(100_000_000)
+	records := []latestRecord{
+		{entry: "robot-1", time: 90_000_000, labels: reductgo.LabelMap{"firmware": "1.2", "battery": "80", "mode": "auto"}},
+		{entry: "robot-2", time: 40_000_000, labels: reductgo.LabelMap{"firmware": "1.3.0", "battery": "12.5"}},
+	}
+
+	frame := getSnapshotFrame(records, now)
+
+	require.Len(t, frame.Fields, 6)
+	names := make([]string, len(frame.Fields))
+	for i, field := range frame.Fields {
+		names[i] = field.Name
+	}
+	assert.Equal(t, []string{"entry", "time", "age", "battery", "firmware", "mode"}, names)
+
+	assert.Equal(t, "robot-2", frame.Fields[0].At(1))
+	assert.Equal(t, time.UnixMicro(90_000_000), frame.Fields[1].At(0))
+	assert.Equal(t, 10.0, frame.Fields[2].At(0))
+	assert.Equal(t, 60.0, frame.Fields[2].At(1))
+
+	// integers and floats share a float column, mixed types fall back to strings
+	assert.Equal(t, 80.0, *frame.Fields[3].At(0).(*float64))
+	assert.Equal(t, 12.5, *frame.Fields[3].At(1).(*float64))
+	assert.Equal(t, "1.2", *frame.Fields[4].At(0).(*string))
+	assert.Equal(t, "1.3.0", *frame.Fields[4].At(1).(*string))
+
+	assert.Equal(t, "auto", *frame.Fields[5].At(0).(*string))
+	assert.Nil(t, frame.Fields[5].At(1))
+}
+
+func TestGetSnapshotFrame_Empty(t *testing.T) {
+	frame := getSnapshotFrame(nil, time.Now())
+	assert.Equal(t, 0, frame.Rows())
+	assert.Len(t, frame.Fields, 3)
+}
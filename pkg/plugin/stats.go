@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+)
+
+// defaultStatsInterval is used when Grafana doesn't provide an interval for the query.
+const defaultStatsInterval = time.Minute
+
+// maxStatsWindows limits the number of windows per series to protect the plugin from tiny intervals.
+const maxStatsWindows = 100_000
+
+type statsWindow struct {
+	records int64
+	bytes   int64
+}
+
+// getStatsFrames counts the records and sums their content length per entry in fixed time windows.
+// Every entry gets two frames, "<entry>/records" and "<entry>/bytes", with a row for each window
+// of the time range so that windows without records are reported as zero.
+func getStatsFrames(records <-chan *reductgo.ReadableRecord, from, to time.Time, interval time.Duration) []*data.Frame {
+	step := statsStep(from, to, interval)
+	windows := make(map[string]map[int64]*statsWindow)
+
+	for record := range records {
+		perEntry, ok := windows[record.Entry()]
+		if !ok {
+			perEntry = make(map[int64]*statsWindow)
+			windows[record.Entry()] = perEntry
+		}
+
+		start := alignTime(record.Time(), step)
+		window, ok := perEntry[start]
+		if !ok {
+			window = &statsWindow{}
+			perEntry[start] = window
+		}
+		window.records++
+		window.bytes += record.Size()
+	}
+
+	entries := make([]string, 0, len(windows))
+	for entry := range windows {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+
+	result := make([]*data.Frame, 0, 2*len(entries))
+	for _, entry := range entries {
+		times, counts, sizes := statsSeries(windows[entry], from, to, step)
+		result = append(result,
+			newStatsFrame(entry+"/records", times, counts),
+			newStatsFrame(entry+"/bytes", times, sizes),
+		)
+	}
+	return result
+}
+
+// statsSeries expands the sparse windows of an entry into dense series covering the time range.
+func statsSeries(windows map[int64]*statsWindow, from, to time.Time, step int64) ([]time.Time, []int64, []int64) {
+	var starts []int64
+	if from.IsZero() || to.IsZero() {
+		for start := range windows {
+			starts = append(starts, start)
+		}
+		sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	} else {
+		for start := alignTime(from.UnixMicro(), step); start <= to.UnixMicro(); start += step {
+			starts = append(starts, start)
+		}
+	}
+
+	times := make([]time.Time, len(starts))
+	counts := make([]int64, len(starts))
+	sizes := make([]int64, len(starts))
+	for i, start := range starts {
+		times[i] = time.UnixMicro(start)
+		if window, ok := windows[start]; ok {
+			counts[i] = window.records
+			sizes[i] = window.bytes
+		}
+	}
+	return times, counts, sizes
+}
+
+func newStatsFrame(name string, times []time.Time, values []int64) *data.Frame {
+	frame := data.NewFrame(name,
+		data.NewField("time", nil, times),
+		data.NewField("value", nil, values),
+	)
+	frame.Meta = &data.FrameMeta{
+		Type: data.FrameTypeTimeSeriesWide,
+	}
+	return frame
+}
+
+// statsStep returns the window size in microseconds, widening it if the range would produce too many windows.
+func statsStep(from, to time.Time, interval time.Duration) int64 {
+	if interval <= 0 {
+		interval = defaultStatsInterval
+	}
+
+	step := interval.Microseconds()
+	if step <= 0 {
+		step = 1
+	}
+
+	if !from.IsZero() && !to.IsZero() {
+		span := to.UnixMicro() - from.UnixMicro()
+		if span/step > maxStatsWindows {
+			step = span/maxStatsWindows + 1
+		}
+	}
+	return step
+}
+
+// alignTime truncates a timestamp in microseconds to the start of its window.
+func alignTime(ts int64, step int64) int64 {
+	aligned := ts - ts%step
+	if ts < 0 && ts%step != 0 {
+		aligned -= step
+	}
+	return aligned
+}
@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHeadRecord(entry string, ts int64, size int64, labels reductgo.LabelMap) *reductgo.ReadableRecord {
+	return reductgo.NewReadableRecord(entry, ts, size, true, io.NopCloser(strings.NewReader("")), labels, "")
+}
+
+func TestGetStatsFrames(t *testing.T) {
+	from := time.Unix(0, 0)
+	to := time.Unix(180, 0)
+
+	records := make(chan *reductgo.ReadableRecord, 4)
+	records <- newHeadRecord("a", time.Unix(10, 0).UnixMicro(), 100, nil)
+	records <- newHeadRecord("a", time.Unix(20, 0).UnixMicro(), 50, nil)
+	records <- newHeadRecord("a", time.Unix(130, 0).UnixMicro(), 10, nil)
+	records <- newHeadRecord("b", time.Unix(70, 0).UnixMicro(), 1, nil)
+	close(records)
+
+	frames := getStatsFrames(records, from, to, time.Minute)
+	require.Len(t, frames, 4)
+
+	assert.Equal(t, "a/records", frames[0].Name)
+	assert.Equal(t, 4, frames[0].Rows())
+	assert.Equal(t, int64(2), frames[0].Fields[1].At(0))
+	assert.Equal(t, int64(0), frames[0].Fields[1].At(1))
+	assert.Equal(t, int64(1), frames[0].Fields[1].At(2))
+
+	assert.Equal(t, "a/bytes", frames[1].Name)
+	assert.Equal(t, int64(150), frames[1].Fields[1].At(0))
+	assert.Equal(t, int64(10), frames[1].Fields[1].At(2))
+
+	assert.Equal(t, "b/records", frames[2].Name)
+	assert.Equal(t, int64(1), frames[2].Fields[1].At(1))
+	assert.Equal(t, time.Unix(60, 0), frames[2].Fields[0].At(1))
+}
+
+func TestStatsStep(t *testing.T) {
+	assert.Equal(t, time.Minute.Microseconds(), statsStep(time.Time{}, time.Time{}, 0))
+	assert.Equal(t, int64(1_000_000), statsStep(time.Unix(0, 0), time.Unix(10, 0), time.Second))
+
+	step := statsStep(time.Unix(0, 0), time.Unix(1_000_000, 0), time.Millisecond)
+	assert.Greater(t, step, time.Millisecond.Microseconds())
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// timeShiftLabel is the field label telling which offset a shifted series has.
+const timeShiftLabel = "time_shift"
+
+// parseTimeShift parses a positive offset into the past. Besides the units of time.ParseDuration,
+// whole days and weeks such as "1d" or "2w" are accepted.
+func parseTimeShift(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	var shift time.Duration
+	var err error
+	if unit := s[len(s)-1]; unit == 'd' || unit == 'w' {
+		var n int64
+		n, err = strconv.ParseInt(s[:len(s)-1], 10, 64)
+		shift = time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			shift *= 7
+		}
+	} else {
+		shift, err = time.ParseDuration(s)
+	}
+
+	if err != nil || shift <= 0 {
+		return 0, fmt.Errorf("invalid time shift '%s'", s)
+	}
+	return shift, nil
+}
+
+// shiftFrames moves the times of the frames forward by the shift, so that a series queried in the past
+// is drawn over the current range, and labels the other fields with the offset.
+func shiftFrames(frames []*data.Frame, shift time.Duration, offset string) {
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			switch field.Type() {
+			case data.FieldTypeTime:
+				for i := 0; i < field.Len(); i++ {
+					field.Set(i, field.At(i).(time.Time).Add(shift))
+				}
+			case data.FieldTypeNullableTime:
+				for i := 0; i < field.Len(); i++ {
+					if t := field.At(i).(*time.Time); t != nil {
+						shifted := t.Add(shift)
+						field.Set(i, &shifted)
+					}
+				}
+			default:
+				if field.Labels == nil {
+					field.Labels = data.Labels{}
+				}
+				field.Labels[timeShiftLabel] = offset
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeShift(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":    0,
+		"90m": 90 * time.Minute,
+		"1d":  24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+	}
+	for s, expected := range tests {
+		shift, err := parseTimeShift(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, shift, s)
+	}
+
+	for _, s := range []string{"-1h", "0s", "xd", "week"} {
+		_, err := parseTimeShift(s)
+		assert.EqualError(t, err, "invalid time shift '"+s+"'")
+	}
+}
+
+func TestShiftFrames(t *testing.T) {
+	start := time.UnixMicro(1_000_000)
+	end := start.Add(time.Second)
+	frame := data.NewFrame("entry/$.value",
+		data.NewField("time", nil, []time.Time{start}),
+		data.NewField("timeEnd", nil, []*time.Time{&end}),
+		data.NewField("value", data.Labels{"sensor": "a"}, []float64{1}),
+	)
+
+	shiftFrames([]*data.Frame{frame}, 24*time.Hour, "1d")
+
+	assert.Equal(t, start.Add(24*time.Hour), frame.Fields[0].At(0))
+	assert.Equal(t, end.Add(24*time.Hour), *frame.Fields[1].At(0).(*time.Time))
+	assert.Equal(t, data.Labels{"sensor": "a", "time_shift": "1d"}, frame.Fields[2].Labels)
+}
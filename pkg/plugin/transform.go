@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Transform types.
+const (
+	TransformRate                  = "rate"
+	TransformDelta                 = "delta"
+	TransformNonNegativeDerivative = "non_negative_derivative"
+	TransformCumulativeSum         = "cumulative_sum"
+	TransformMovingAverage         = "moving_average"
+)
+
+// transformOptions derives a series from another one. Window is the number of points of a moving average.
+type transformOptions struct {
+	Type   string `json:"type"`
+	Window int    `json:"window,omitempty"`
+}
+
+// validate checks the type and the parameters of the transform.
+func (t transformOptions) validate() error {
+	switch t.Type {
+	case TransformRate, TransformDelta, TransformNonNegativeDerivative, TransformCumulativeSum:
+		return nil
+	case TransformMovingAverage:
+		if t.Window <= 0 {
+			return fmt.Errorf("transform %s needs a positive window", t.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown transform: %s", t.Type)
+	}
+}
+
+// transformFrames applies the transforms in order to every numeric field of the time series frames.
+// The transformed fields hold nullable floats because the first point of a difference has no value.
+func transformFrames(frames []*data.Frame, transforms []transformOptions) {
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 || frame.Fields[0].Type() != data.FieldTypeTime {
+			continue
+		}
+
+		times := make([]time.Time, frame.Fields[0].Len())
+		for i := range times {
+			times[i] = frame.Fields[0].At(i).(time.Time)
+		}
+
+		for i, field := range frame.Fields[1:] {
+			if !field.Type().Numeric() {
+				continue
+			}
+
+			values := make([]*float64, field.Len())
+			for j := range values {
+				v, err := field.NullableFloatAt(j)
+				if err == nil {
+					values[j] = v
+				}
+			}
+
+			for _, t := range transforms {
+				values = applyTransform(t, times, values)
+			}
+
+			transformed := data.NewField(field.Name, field.Labels, values)
+			transformed.Config = field.Config
+			frame.Fields[i+1] = transformed
+		}
+	}
+}
+
+// applyTransform computes a transform over the values. Null values are skipped and stay null.
+func applyTransform(t transformOptions, times []time.Time, values []*float64) []*float64 {
+	result := make([]*float64, len(values))
+	prev := -1
+	var sum float64
+	var window []float64
+
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+
+		switch t.Type {
+		case TransformDelta, TransformRate, TransformNonNegativeDerivative:
+			if prev >= 0 {
+				diff := *v - *values[prev]
+				if t.Type == TransformNonNegativeDerivative && diff < 0 {
+					// the counter was reset, so it counted up from zero since the previous point
+					diff = *v
+				}
+				if t.Type == TransformDelta {
+					result[i] = &diff
+				} else if dt := times[i].Sub(times[prev]).Seconds(); dt > 0 {
+					rate := diff / dt
+					result[i] = &rate
+				}
+			}
+			prev = i
+		case TransformCumulativeSum:
+			sum += *v
+			total := sum
+			result[i] = &total
+		case TransformMovingAverage:
+			window = append(window, *v)
+			if len(window) > t.Window {
+				window = window[1:]
+			}
+			mean := 0.0
+			for _, w := range window {
+				mean += w
+			}
+			mean /= float64(len(window))
+			result[i] = &mean
+		}
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func floats(values ...any) []*float64 {
+	result := make([]*float64, len(values))
+	for i, v := range values {
+		if f, ok := v.(float64); ok {
+			result[i] = &f
+		}
+	}
+	return result
+}
+
+func TestTransformValidate(t *testing.T) {
+	assert.NoError(t, transformOptions{Type: TransformRate}.validate())
+	assert.NoError(t, transformOptions{Type: TransformMovingAverage, Window: 3}.validate())
+	assert.EqualError(t, transformOptions{Type: TransformMovingAverage}.validate(), "transform moving_average needs a positive window")
+	assert.EqualError(t, transformOptions{Type: "integral"}.validate(), "unknown transform: integral")
+}
+
+func TestApplyTransform(t *testing.T) {
+	times := []time.Time{time.Unix(0, 0), time.Unix(2, 0), time.Unix(4, 0), time.Unix(6, 0), time.Unix(8, 0)}
+	values := floats(10.0, 14.0, nil, 2.0, 6.0)
+
+	assert.Equal(t, floats(nil, 4.0, nil, -12.0, 4.0), applyTransform(transformOptions{Type: TransformDelta}, times, values))
+	assert.Equal(t, floats(nil, 2.0, nil, -3.0, 2.0), applyTransform(transformOptions{Type: TransformRate}, times, values))
+	assert.Equal(t, floats(nil, 2.0, nil, 0.5, 2.0), applyTransform(transformOptions{Type: TransformNonNegativeDerivative}, times, values))
+	assert.Equal(t, floats(10.0, 24.0, nil, 26.0, 32.0), applyTransform(transformOptions{Type: TransformCumulativeSum}, times, values))
+	assert.Equal(t, floats(10.0, 12.0, nil, 8.0, 4.0), applyTransform(transformOptions{Type: TransformMovingAverage, Window: 2}, times, values))
+}
+
+func TestTransformFrames(t *testing.T) {
+	frame := data.NewFrame("robot/odometer",
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(10, 0), time.Unix(20, 0)}),
+		data.NewField("value", data.Labels{"robot_id": "r1"}, []int64{100, 150, 250}),
+		data.NewField("state", nil, []string{"a", "b", "c"}),
+	)
+
+	transformFrames([]*data.Frame{frame}, []transformOptions{
+		{Type: TransformDelta},
+		{Type: TransformCumulativeSum},
+	})
+
+	assert.Equal(t, data.NewField("value", data.Labels{"robot_id": "r1"}, floats(nil, 50.0, 150.0)), frame.Fields[1])
+	assert.Equal(t, data.NewField("state", nil, []string{"a", "b", "c"}), frame.Fields[2])
+}
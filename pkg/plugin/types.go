@@ -6,21 +6,110 @@ const (
 	ModeLabelOnly       ReductMode = "LabelOnly"
 	ModeContentOnly     ReductMode = "ContentOnly"
 	ModeLabelAndContent ReductMode = "LabelAndContent"
+	ModeLogs            ReductMode = "Logs"
 )
 
+type ReductQueryType string
+
+const (
+	// QueryTypeRecords returns labels and/or content of the records. It is the default query type.
+	QueryTypeRecords ReductQueryType = ""
+	// QueryTypeStats returns record counts and content sizes per interval.
+	QueryTypeStats ReductQueryType = "stats"
+	// QueryTypeInventory returns a table with the metadata of the entries.
+	QueryTypeInventory ReductQueryType = "inventory"
+	// QueryTypeCapacity returns the server usage and the bucket sizes against their quotas.
+	QueryTypeCapacity ReductQueryType = "capacity"
+	// QueryTypeAnnotations turns records into annotations.
+	QueryTypeAnnotations ReductQueryType = "annotations"
+	// QueryTypeVariable returns the values of a template variable.
+	QueryTypeVariable ReductQueryType = "variable"
+	// QueryTypeLatest returns the labels and content of the newest record of every entry.
+	QueryTypeLatest ReductQueryType = "latest"
+	// QueryTypeSnapshot returns a table with the latest labels of the entries.
+	QueryTypeSnapshot ReductQueryType = "snapshot"
+)
+
+// scansRecords reports whether the query type reads records and can share a scan with other queries.
+func (t ReductQueryType) scansRecords() bool {
+	return t == QueryTypeRecords || t == QueryTypeStats || t == QueryTypeAnnotations
+}
+
+// requiresBucket reports whether the query type needs a bucket.
+func (t ReductQueryType) requiresBucket() bool {
+	return t != QueryTypeCapacity && t != QueryTypeVariable
+}
+
+// requiresEntries reports whether the query type needs at least one entry or entry pattern.
+func (t ReductQueryType) requiresEntries() bool {
+	return t.scansRecords() || t == QueryTypeLatest || t == QueryTypeSnapshot
+}
+
+func (t ReductQueryType) isValid() bool {
+	switch t {
+	case QueryTypeRecords, QueryTypeStats, QueryTypeInventory, QueryTypeCapacity, QueryTypeAnnotations,
+		QueryTypeVariable, QueryTypeLatest, QueryTypeSnapshot:
+		return true
+	default:
+		return false
+	}
+}
+
 type reductOptions struct {
-	Start      int64      `json:"start,omitempty"`
-	Stop       int64      `json:"stop,omitempty"`
-	When       any        `json:"when,omitempty"`
-	Strict     bool       `json:"strict,omitempty"`
-	Continuous bool       `json:"continuous,omitempty"`
-	Ext        any        `json:"ext,omitempty"`
-	Mode       ReductMode `json:"mode,omitempty"`
+	Start        int64               `json:"start,omitempty"`
+	Stop         int64               `json:"stop,omitempty"`
+	When         any                 `json:"when,omitempty"`
+	Strict       bool                `json:"strict,omitempty"`
+	Continuous   bool                `json:"continuous,omitempty"`
+	Ext          any                 `json:"ext,omitempty"`
+	Mode         ReductMode          `json:"mode,omitempty"`
+	Severity     string              `json:"severity,omitempty"`
+	Annotations  annotationOptions   `json:"annotations,omitempty"`
+	Variable     variableOptions     `json:"variable,omitempty"`
+	RecordLinks  bool                `json:"recordLinks,omitempty"`
+	QueryLinks   queryLinkOptions    `json:"queryLinks,omitempty"`
+	GroupBy      []string            `json:"groupBy,omitempty"`
+	Aggregations []string            `json:"aggregations,omitempty"`
+	Transforms   []transformOptions  `json:"transforms,omitempty"`
+	GapFill      gapFillOptions      `json:"gapFill,omitempty"`
+	Align        alignOptions        `json:"align,omitempty"`
+	Expressions  []expressionOptions `json:"expressions,omitempty"`
+	TimeShift    string              `json:"timeShift,omitempty"`
+}
+
+// labelMatch selects records having a label with the given value.
+type labelMatch struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// annotationOptions maps record labels onto annotation fields.
+type annotationOptions struct {
+	Title       string      `json:"title,omitempty"`
+	Text        string      `json:"text,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	RegionStart *labelMatch `json:"regionStart,omitempty"`
+	RegionEnd   *labelMatch `json:"regionEnd,omitempty"`
+}
+
+// variableOptions selects what a variable query lists. Label is used by the label values kind.
+type variableOptions struct {
+	Kind  VariableKind `json:"kind"`
+	Label string       `json:"label,omitempty"`
+}
+
+// adhocFilter is a Grafana ad-hoc filter forwarded by the frontend with the query.
+type adhocFilter struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
 }
 
 type reductQuery struct {
-	Bucket  string        `json:"bucket"`
-	Entry   string        `json:"entry"`
-	Entries []string      `json:"entries"`
-	Options reductOptions `json:"options"`
+	QueryType    ReductQueryType `json:"queryType,omitempty"`
+	Bucket       string          `json:"bucket"`
+	Entry        string          `json:"entry"`
+	Entries      []string        `json:"entries"`
+	Options      reductOptions   `json:"options"`
+	AdhocFilters []adhocFilter   `json:"adhocFilters,omitempty"`
 }
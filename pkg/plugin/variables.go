@@ -0,0 +1,171 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	reductgo "github.com/reductstore/reduct-go"
+	model "github.com/reductstore/reduct-go/model"
+)
+
+type VariableKind string
+
+const (
+	VariableBuckets     VariableKind = "buckets"
+	VariableEntries     VariableKind = "entries"
+	VariableLabelKeys   VariableKind = "labelKeys"
+	VariableLabelValues VariableKind = "labelValues"
+)
+
+// labelKeySampleSize is the number of records read to discover label keys.
+const labelKeySampleSize = 1000
+
+// maxLabelValueRecords limits the number of records read to collect distinct label values.
+const maxLabelValueRecords = 100_000
+
+// queryVariable returns a single-field frame with the values of a template variable.
+func (d *ReductDatasource) queryVariable(ctx context.Context, q plannedQuery) backend.DataResponse {
+	options := q.query.Options.Variable
+
+	if options.Kind == VariableBuckets {
+		buckets, err := d.reductClient.GetBuckets(ctx)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to get buckets", "error", err)
+			var apiErr model.APIError
+			errors.As(err, &apiErr)
+			return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+		}
+
+		names := make([]string, 0, len(buckets))
+		for _, bucket := range buckets {
+			names = append(names, bucket.Name)
+		}
+		return newVariableResponse("bucket", names)
+	}
+
+	if q.query.Bucket == "" {
+		return backend.ErrDataResponse(backend.StatusBadRequest, "missing bucket")
+	}
+
+	switch options.Kind {
+	case VariableEntries:
+		bucket, err := d.reductClient.GetBucket(ctx, q.query.Bucket)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to get bucket", "error", err)
+			var apiErr model.APIError
+			errors.As(err, &apiErr)
+			return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+		}
+
+		entries, err := bucket.GetEntries(ctx)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to list entries", "error", err)
+			var apiErr model.APIError
+			errors.As(err, &apiErr)
+			return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+		}
+
+		names := make([]string, 0, len(entries))
+		for _, entry := range filterEntries(entries, q.entries) {
+			names = append(names, entry.Name)
+		}
+		return newVariableResponse("entry", names)
+	case VariableLabelKeys, VariableLabelValues:
+		field, limit := "label", labelKeySampleSize
+		if options.Kind == VariableLabelValues {
+			if options.Label == "" {
+				return backend.ErrDataResponse(backend.StatusBadRequest, "missing label for label values")
+			}
+			field, limit = "value", maxLabelValueRecords
+		}
+
+		records, err := d.sampleRecords(ctx, q.query.Bucket, q.entries, q.query.Options.When, q.from, q.to, limit)
+		if err != nil {
+			log.DefaultLogger.Error("Failed to query", "error", err)
+			var apiErr model.APIError
+			if !errors.As(err, &apiErr) {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+			return backend.ErrDataResponse(backend.Status(apiErr.Status), apiErr.Message)
+		}
+
+		if options.Kind == VariableLabelKeys {
+			return newVariableResponse(field, collectLabelKeys(records))
+		}
+		return newVariableResponse(field, collectLabelValues(records, options.Label))
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("unknown variable kind: %s", options.Kind))
+	}
+}
+
+// withLimit returns the condition with $limit set, keeping a lower limit of the query.
+func withLimit(when any, limit int) (map[string]any, error) {
+	condition, err := parseAndNormalizeCondition(when)
+	if err != nil {
+		return nil, err
+	}
+
+	if current, ok := condition["$limit"].(float64); !ok || int(current) > limit {
+		condition["$limit"] = limit
+	}
+	return condition, nil
+}
+
+// headQueryOptions builds options for a head-only query over the time range.
+func headQueryOptions(when any, from, to time.Time) reductgo.QueryOptions {
+	options := reductgo.NewQueryOptionsBuilder().WithWhen(when).WithHead(true)
+	if !from.IsZero() {
+		options.WithStart(from.UnixMicro())
+	}
+	if !to.IsZero() {
+		options.WithStop(to.UnixMicro())
+	}
+	return options.Build()
+}
+
+// collectLabelKeys returns the sorted set of label keys found in the records.
+func collectLabelKeys(records <-chan *reductgo.ReadableRecord) []string {
+	keys := make(map[string]struct{})
+	for record := range records {
+		for key := range record.Labels() {
+			keys[key] = struct{}{}
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// collectLabelValues returns the sorted set of values of the label found in the records.
+func collectLabelValues(records <-chan *reductgo.ReadableRecord, label string) []string {
+	values := make(map[string]struct{})
+	for record := range records {
+		if value, ok := record.Labels()[label]; ok {
+			values[fmt.Sprintf("%v", value)] = struct{}{}
+		}
+	}
+	return sortedKeys(values)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newVariableResponse(name string, values []string) backend.DataResponse {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return backend.DataResponse{
+		Frames: []*data.Frame{
+			data.NewFrame(name, data.NewField(name, nil, sorted)),
+		},
+	}
+}
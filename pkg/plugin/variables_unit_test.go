@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"testing"
+
+	reductgo "github.com/reductstore/reduct-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectLabels(t *testing.T) {
+	newRecords := func() <-chan *reductgo.ReadableRecord {
+		records := make(chan *reductgo.ReadableRecord, 3)
+		records <- newHeadRecord("a", 1, 0, reductgo.LabelMap{"robot": "r2", "mode": "auto"})
+		records <- newHeadRecord("a", 2, 0, reductgo.LabelMap{"robot": "r1"})
+		records <- newHeadRecord("b", 3, 0, reductgo.LabelMap{"robot": "r2", "battery": 90})
+		close(records)
+		return records
+	}
+
+	assert.Equal(t, []string{"battery", "mode", "robot"}, collectLabelKeys(newRecords()))
+	assert.Equal(t, []string{"r1", "r2"}, collectLabelValues(newRecords(), "robot"))
+	assert.Empty(t, collectLabelValues(newRecords(), "missing"))
+}
+
+func TestWithLimit(t *testing.T) {
+	condition, err := withLimit(nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, condition["$limit"])
+
+	condition, err = withLimit(`{"&robot": {"$eq": "r1"}, "$limit": 5}`, 10)
+	require.NoError(t, err)
+	assert.Equal(t, float64(5), condition["$limit"])
+	assert.Contains(t, condition, "&robot")
+
+	_, err = withLimit("not-json", 10)
+	assert.Error(t, err)
+}
+
+func TestNewVariableResponse(t *testing.T) {
+	res := newVariableResponse("entry", []string{"b", "a"})
+	require.Len(t, res.Frames, 1)
+	assert.Equal(t, 2, res.Frames[0].Rows())
+	assert.Equal(t, "a", res.Frames[0].Fields[0].At(0))
+
+	res = newVariableResponse("label", nil)
+	assert.Equal(t, 0, res.Frames[0].Rows())
+}
@@ -1,5 +1,5 @@
 import React, { useEffect, useState, useCallback, useMemo } from 'react';
-import { InlineField, InlineFieldRow } from '@grafana/ui';
+import { InlineField, InlineFieldRow, Input } from '@grafana/ui';
 import { getBackendSrv, getTemplateSrv } from '@grafana/runtime';
 import { QueryEditorProps, SelectableValue } from '@grafana/data';
 import { DataMode, ReductQuery, ReductSourceOptions } from '../types';
@@ -15,6 +15,7 @@ export function QueryEditor({ query, onChange, onRunQuery, datasource }: Props)
   const [buckets, setBuckets] = useState<Array<SelectableValue<string>>>([]);
   const [bucketsLoading, setBucketsLoading] = useState(true);
   const [entries, setEntries] = useState<Array<SelectableValue<string>>>([]);
+  const [severity, setSeverity] = useState(query.options?.severity ?? '');
 
   const bucket = query.bucket;
   const queryEntries = useMemo(() => query.entries ?? (query.entry ? [query.entry] : []), [query.entries, query.entry]);
@@ -24,6 +25,7 @@ export function QueryEditor({ query, onChange, onRunQuery, datasource }: Props)
     { label: 'Label Only', value: DataMode.LabelOnly },
     { label: 'Content Only', value: DataMode.ContentOnly },
     { label: 'Label & Content', value: DataMode.LabelAndContent },
+    { label: 'Logs', value: DataMode.Logs },
   ];
 
   const templateVariables = useMemo(
@@ -116,6 +118,13 @@ export function QueryEditor({ query, onChange, onRunQuery, datasource }: Props)
     [bucket, queryEntries, updateQuery]
   );
 
+  const onSeverityBlur = useCallback(() => {
+    onChange({ ...query, options: { ...(query.options ?? {}), severity: severity || undefined } });
+    if (bucket && queryEntries.length > 0) {
+      onRunQuery();
+    }
+  }, [query, severity, bucket, queryEntries, onChange, onRunQuery]);
+
   // Handle changes from JSON editor
   const handleEditorChange = useCallback(
     (newQuery: ReductQuery, process: boolean) => {
@@ -149,7 +158,7 @@ export function QueryEditor({ query, onChange, onRunQuery, datasource }: Props)
             onChange={onEntriesChange}
           />
         </InlineField>
-        <InlineField label="Scope" tooltip="Controls what the query returns: labels only, content only, or both">
+        <InlineField label="Scope" tooltip="Controls what the query returns: labels only, content only, both, or log lines">
           <div style={{ width: 150 }}>
             <CompatibleSelect
               testId="scope-picker"
@@ -159,6 +168,18 @@ export function QueryEditor({ query, onChange, onRunQuery, datasource }: Props)
             />
           </div>
         </InlineField>
+        {mode === DataMode.Logs && (
+          <InlineField label="Severity" tooltip="Label name or JSON path (e.g., $.level) holding the log level">
+            <Input
+              data-testid="severity-input"
+              value={severity}
+              placeholder="level"
+              onChange={(e) => setSeverity(e.currentTarget.value)}
+              onBlur={onSeverityBlur}
+              width={20}
+            />
+          </InlineField>
+        )}
       </InlineFieldRow>
       <InlineFieldRow>
         <InlineField grow>
@@ -0,0 +1,131 @@
+import React, { useEffect, useMemo, useState } from 'react';
+import { InlineField, InlineFieldRow, Input } from '@grafana/ui';
+import { getBackendSrv, getTemplateSrv } from '@grafana/runtime';
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { ReductQuery, ReductSourceOptions, VariableKind } from '../types';
+import { DataSource } from '../datasource';
+import { CompatibleSelect } from './CompatibleSelect';
+import { EntryInput } from './EntryInput';
+
+type Props = QueryEditorProps<DataSource, ReductQuery, ReductSourceOptions>;
+
+const kindOptions: Array<SelectableValue<VariableKind>> = [
+  { label: 'Buckets', value: 'buckets' },
+  { label: 'Entries', value: 'entries' },
+  { label: 'Label keys', value: 'labelKeys' },
+  { label: 'Label values', value: 'labelValues' },
+];
+
+/**
+ * Editor of a template variable listing buckets, entries, label keys or the values of a label.
+ */
+export function VariableQueryEditor({ query, onChange, datasource }: Props) {
+  const [buckets, setBuckets] = useState<Array<SelectableValue<string>>>([]);
+  const [entries, setEntries] = useState<Array<SelectableValue<string>>>([]);
+  const [label, setLabel] = useState(query.options?.variable?.label ?? '');
+
+  const kind = query.options?.variable?.kind ?? 'entries';
+  const bucket = query.bucket;
+  const queryEntries = query.entries ?? (query.entry ? [query.entry] : []);
+
+  const templateVariables = useMemo(
+    () =>
+      getTemplateSrv()
+        .getVariables()
+        .map((v) => ({ label: `$${v.name}`, value: `$${v.name}` })),
+    []
+  );
+
+  useEffect(() => {
+    getBackendSrv()
+      .get(`/api/datasources/uid/${datasource.uid}/resources/listBuckets`, undefined, undefined, {
+        showErrorAlert: false,
+      })
+      .then((res) => setBuckets(res.map((b: any) => ({ label: b.name, value: b.name }))))
+      .catch((error) => {
+        console.warn('Failed to load buckets:', error);
+        setBuckets([]);
+      });
+  }, [datasource.uid]);
+
+  useEffect(() => {
+    if (!bucket) {
+      setEntries([]);
+      return;
+    }
+
+    getBackendSrv()
+      .post(
+        `/api/datasources/uid/${datasource.uid}/resources/listEntries`,
+        { bucket: getTemplateSrv().replace(bucket) },
+        { showErrorAlert: false }
+      )
+      .then((res) => setEntries(res.map((e: any) => ({ label: e.name, value: e.name }))))
+      .catch((error) => {
+        console.warn('Failed to load entries:', error);
+        setEntries([]);
+      });
+  }, [bucket, datasource.uid]);
+
+  const update = (changes: Partial<ReductQuery>, newKind: VariableKind, newLabel: string) => {
+    onChange({
+      ...query,
+      ...changes,
+      queryType: 'variable',
+      options: {
+        ...(query.options ?? {}),
+        variable: { kind: newKind, label: newKind === 'labelValues' ? newLabel : undefined },
+      },
+    });
+  };
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Values" tooltip="What the variable lists">
+          <div style={{ width: 150 }}>
+            <CompatibleSelect
+              testId="variable-kind-picker"
+              options={kindOptions}
+              value={kindOptions.find((k) => k.value === kind)}
+              onChange={(v) => update({}, v?.value ?? 'entries', label)}
+            />
+          </div>
+        </InlineField>
+        {kind !== 'buckets' && (
+          <InlineField label="Bucket" tooltip="The bucket to list from" grow>
+            <CompatibleSelect
+              testId="variable-bucket-picker"
+              options={[...templateVariables, ...buckets]}
+              value={[...templateVariables, ...buckets].find((b) => b.value === bucket)}
+              onChange={(v) => update({ bucket: v?.value, entry: undefined, entries: [] }, kind, label)}
+            />
+          </InlineField>
+        )}
+      </InlineFieldRow>
+      {kind !== 'buckets' && (
+        <InlineFieldRow>
+          <InlineField label="Entry" tooltip="Entry name(s) or wildcard pattern (e.g., sensor-*)" grow>
+            <EntryInput
+              testId="variable-entry-picker"
+              options={[...templateVariables, ...entries]}
+              values={queryEntries}
+              onChange={(values) => update({ entry: undefined, entries: values }, kind, label)}
+            />
+          </InlineField>
+          {kind === 'labelValues' && (
+            <InlineField label="Label" tooltip="The label whose values are listed">
+              <Input
+                value={label}
+                placeholder="robot_id"
+                onChange={(e) => setLabel(e.currentTarget.value)}
+                onBlur={() => update({}, kind, label)}
+                width={20}
+              />
+            </InlineField>
+          )}
+        </InlineFieldRow>
+      )}
+    </>
+  );
+}
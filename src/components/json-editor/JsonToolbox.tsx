@@ -69,9 +69,9 @@ export function JsonToolbox({ query, formatCode, onExpand, isExpanded, datasourc
 
       const templateSrv = getTemplateSrv();
       const bucket = templateSrv.replace(query.bucket);
-      const entry = templateSrv.replace(
-        query.entry || (query.entries && query.entries.length > 0 ? query.entries[0] : undefined)
-      );
+      const entries = (query.entries && query.entries.length > 0 ? query.entries : [query.entry])
+        .filter((entry): entry is string => !!entry)
+        .map((entry) => templateSrv.replace(entry));
       const resolvedWhen = replaceWhenTemplateVariables(when);
 
       getBackendSrv()
@@ -79,7 +79,7 @@ export function JsonToolbox({ query, formatCode, onExpand, isExpanded, datasourc
           `/api/datasources/uid/${datasourceUid}/resources/validateCondition`,
           {
             bucket,
-            entry,
+            entries,
             condition: resolvedWhen,
           },
           {
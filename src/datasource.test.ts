@@ -123,6 +123,10 @@ describe('DataSource helpers', () => {
     expect(ds.filterQuery({ bucket: 'b', entry: '' } as any)).toBe(false);
   });
 
+  it('keeps variable queries without entries', () => {
+    expect(ds.filterQuery({ queryType: 'variable', options: { variable: { kind: 'buckets' } } } as any)).toBe(true);
+  });
+
   it('injects range into query options while preserving other fields', () => {
     const result = ds.prepareQuery(
       {
@@ -143,3 +147,19 @@ describe('DataSource helpers', () => {
     expect(result.options?.when).toEqual({ $eq: 1 });
   });
 });
+
+describe('ReductVariableSupport', () => {
+  it('runs the targets as variable queries', () => {
+    const ds = new DataSource({} as any);
+    const query = jest.fn();
+    (ds as any).query = query;
+
+    (ds.variables as any).query({
+      targets: [{ refId: 'A', bucket: 'b', options: { variable: { kind: 'entries' } } }],
+    });
+
+    expect(query).toHaveBeenCalledWith({
+      targets: [{ refId: 'A', bucket: 'b', queryType: 'variable', options: { variable: { kind: 'entries' } } }],
+    });
+  });
+});
@@ -1,15 +1,29 @@
-import { DataSourceInstanceSettings, ScopedVars, DataQueryRequest } from '@grafana/data';
+import {
+  AdHocVariableFilter,
+  CustomVariableSupport,
+  DataQueryRequest,
+  DataSourceGetTagKeysOptions,
+  DataSourceGetTagValuesOptions,
+  DataSourceInstanceSettings,
+  MetricFindValue,
+  ScopedVars,
+} from '@grafana/data';
 import { DataSourceWithBackend, getTemplateSrv } from '@grafana/runtime';
 
+import { VariableQueryEditor } from './components/VariableQueryEditor';
 import { replaceWhenTemplateVariables } from './templateUtils';
 import { ReductQuery, ReductSourceOptions } from './types';
 
 export class DataSource extends DataSourceWithBackend<ReductQuery, ReductSourceOptions> {
   constructor(instanceSettings: DataSourceInstanceSettings<ReductSourceOptions>) {
     super(instanceSettings);
+    this.annotations = {
+      prepareQuery: (anno) => (anno.target ? { ...anno.target, queryType: 'annotations' } : undefined),
+    };
+    this.variables = new ReductVariableSupport(this);
   }
 
-  applyTemplateVariables(query: ReductQuery, scopedVars: ScopedVars): ReductQuery {
+  applyTemplateVariables(query: ReductQuery, scopedVars: ScopedVars, filters?: AdHocVariableFilter[]): ReductQuery {
     const templateSrv = getTemplateSrv();
 
     return {
@@ -22,11 +36,41 @@ export class DataSource extends DataSourceWithBackend<ReductQuery, ReductSourceO
         start: Number(templateSrv.replace(query.options?.start?.toString(), scopedVars)) || undefined,
         stop: Number(templateSrv.replace(query.options?.stop?.toString(), scopedVars)) || undefined,
         when: replaceWhenTemplateVariables(query.options?.when, scopedVars),
+        variable: query.options?.variable && {
+          ...query.options.variable,
+          label: query.options.variable.label && templateSrv.replace(query.options.variable.label, scopedVars),
+        },
       },
+      adhocFilters: filters?.map(({ key, operator, value }) => ({ key, operator, value })),
+    };
+  }
+
+  async getTagKeys(options?: DataSourceGetTagKeysOptions<ReductQuery>): Promise<MetricFindValue[]> {
+    return this.postResource('tagKeys', this.tagSampleScope(options));
+  }
+
+  async getTagValues(options: DataSourceGetTagValuesOptions<ReductQuery>): Promise<MetricFindValue[]> {
+    return this.postResource('tagValues', { ...this.tagSampleScope(options), key: options.key });
+  }
+
+  /**
+   * Labels are sampled from the bucket and entries of the first panel query, or from all buckets.
+   */
+  private tagSampleScope(options?: DataSourceGetTagKeysOptions<ReductQuery>) {
+    const query = options?.queries?.find((q) => !!q.bucket);
+    return {
+      bucket: query?.bucket,
+      entries: query?.entries ?? (query?.entry ? [query.entry] : undefined),
+      start: options?.timeRange?.from.valueOf(),
+      stop: options?.timeRange?.to.valueOf(),
     };
   }
 
   filterQuery(query: ReductQuery): boolean {
+    // a variable listing buckets doesn't need a bucket, the backend checks the others
+    if (query.queryType === 'variable') {
+      return true;
+    }
     const hasEntries = (query.entries && query.entries.length > 0) || !!query.entry;
     return !!query.bucket && hasEntries;
   }
@@ -46,3 +90,22 @@ export class DataSource extends DataSourceWithBackend<ReductQuery, ReductSourceO
     };
   }
 }
+
+/**
+ * Lets the datasource provide template variables such as $robot or $sensor through the backend
+ * variable query type.
+ */
+export class ReductVariableSupport extends CustomVariableSupport<DataSource, ReductQuery> {
+  editor = VariableQueryEditor;
+
+  constructor(private readonly datasource: DataSource) {
+    super();
+  }
+
+  query(request: DataQueryRequest<ReductQuery>) {
+    return this.datasource.query({
+      ...request,
+      targets: request.targets.map((target) => ({ ...target, queryType: 'variable' })),
+    });
+  }
+}
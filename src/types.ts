@@ -5,6 +5,7 @@ export enum DataMode {
   LabelOnly = 'LabelOnly',
   ContentOnly = 'ContentOnly',
   LabelAndContent = 'LabelAndContent',
+  Logs = 'Logs',
 }
 
 export interface ReductQuery extends DataQuery {
@@ -12,6 +13,13 @@ export interface ReductQuery extends DataQuery {
   entry?: string;
   entries?: string[];
   options?: QueryOptions;
+  adhocFilters?: AdHocFilter[];
+}
+
+export interface AdHocFilter {
+  key: string;
+  operator: string;
+  value: string;
 }
 
 export type ReductWhenCondition = Record<string, any>;
@@ -24,6 +32,52 @@ export interface QueryOptions {
   strict?: boolean;
   continuous?: boolean;
   mode?: DataMode;
+  /** Label name or JSON path ("$.level") holding the severity of a log line in Logs mode. */
+  severity?: string;
+  recordLinks?: boolean;
+  queryLinks?: QueryLinkOptions;
+  groupBy?: string[];
+  aggregations?: string[];
+  transforms?: TransformOptions[];
+  gapFill?: GapFillOptions;
+  align?: AlignOptions;
+  expressions?: ExpressionOptions[];
+  timeShift?: string;
+  variable?: VariableOptions;
+}
+
+export type VariableKind = 'buckets' | 'entries' | 'labelKeys' | 'labelValues';
+
+export interface VariableOptions {
+  kind: VariableKind;
+  label?: string;
+}
+
+export interface TransformOptions {
+  type: 'rate' | 'delta' | 'non_negative_derivative' | 'cumulative_sum' | 'moving_average';
+  window?: number;
+}
+
+export interface GapFillOptions {
+  mode?: 'null' | 'previous' | 'zero' | 'linear';
+  staleness?: string;
+}
+
+export interface AlignOptions {
+  enabled?: boolean;
+  series?: string[];
+  match?: 'previous' | 'nearest';
+  tolerance?: string;
+}
+
+export interface ExpressionOptions {
+  name: string;
+  expr: string;
+}
+
+export interface QueryLinkOptions {
+  enabled?: boolean;
+  expiry?: string;
 }
 
 /**